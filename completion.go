@@ -22,7 +22,14 @@ func (rl *Shell) completionCommands() commands {
 		"menu-complete-prev-tag":   rl.menuCompletePrevTag,
 		"accept-and-menu-complete": rl.acceptAndMenuComplete,
 		"vi-registers-complete":    rl.viRegistersComplete,
+		"undo-states-complete":     rl.undoStatesComplete,
 		"menu-incremental-search":  rl.menuIncrementalSearch,
+		"complete-filename":        rl.completeFilename,
+
+		"menu-toggle-mark":         rl.menuToggleMark,
+		"menu-accept-marked":       rl.menuAcceptMarked,
+		"menu-preview-scroll-down": rl.menuPreviewScrollDown,
+		"menu-preview-scroll-up":   rl.menuPreviewScrollUp,
 	}
 }
 
@@ -30,8 +37,9 @@ func (rl *Shell) completionCommands() commands {
 // Commands ---------------------------------------------------------------------------
 //
 
-// Attempt completion on the current word.
-// Currently identitical to menu-complete.
+// Attempt completion on the current word. If all candidates share
+// a prefix longer than the word, this prefix is inserted: otherwise
+// this is identical to menu-complete.
 func (rl *Shell) completeWord() {
 	rl.History.SkipSave()
 
@@ -40,6 +48,11 @@ func (rl *Shell) completeWord() {
 	if !rl.completer.IsActive() {
 		rl.startMenuComplete(rl.commandCompletion)
 
+		// If all candidates share a longer prefix, only insert it.
+		if rl.completer.InsertCommonPrefix() {
+			return
+		}
+
 		if rl.Config.GetBool("menu-complete-display-prefix") {
 			return
 		}
@@ -174,6 +187,16 @@ func (rl *Shell) viRegistersComplete() {
 	rl.startMenuComplete(rl.Buffers.Complete)
 }
 
+// Open a completion menu with all undo states of the line, the most recent first, each
+// with a preview. Accepting one restores the line to this state, from which other states
+// remain accessible with undo, redo, undo-earlier and undo-later.
+func (rl *Shell) undoStatesComplete() {
+	rl.History.SkipSave()
+	rl.startMenuComplete(func() completion.Values {
+		return history.CompleteUndo(rl.History)
+	})
+}
+
 // In a menu completion (whether a candidate is selected or not), start incremental-search
 // (fuzzy search) on the results. Search backward incrementally for a specified string.
 // The search is case-insensitive if the search string does not have uppercase letters
@@ -190,6 +213,52 @@ func (rl *Shell) menuIncrementalSearch() {
 	rl.completer.IsearchStart("completions", false, false)
 }
 
+// In a menu completion, mark the current candidate for insertion
+// (or unmark it if already marked), and select the next candidate.
+func (rl *Shell) menuToggleMark() {
+	rl.History.SkipSave()
+
+	if !rl.completer.IsActive() {
+		return
+	}
+
+	if rl.completer.ToggleMark() {
+		rl.completer.Select(1, 0)
+	}
+}
+
+// In a menu completion, insert all marked candidates at once, separated
+// by a space (or by their group list separator, if any), and exit the menu.
+// If no candidates are marked, the currently selected one is inserted.
+func (rl *Shell) menuAcceptMarked() {
+	rl.History.Save()
+
+	if !rl.completer.IsActive() {
+		return
+	}
+
+	rl.completer.AcceptMarked()
+}
+
+// In a menu completion, scroll down the preview of the selected candidate, if any.
+func (rl *Shell) menuPreviewScrollDown() {
+	rl.History.SkipSave()
+	rl.completer.ScrollPreview(true)
+}
+
+// In a menu completion, scroll up the preview of the selected candidate, if any.
+func (rl *Shell) menuPreviewScrollUp() {
+	rl.History.SkipSave()
+	rl.completer.ScrollPreview(false)
+}
+
+// Attempt filename completion on the word before the cursor,
+// regardless of the completions offered by the shell completer.
+func (rl *Shell) completeFilename() {
+	rl.History.SkipSave()
+	rl.startMenuComplete(rl.filenameCompletion)
+}
+
 //
 // Utilities --------------------------------------------------------------------------
 //
@@ -212,6 +281,24 @@ func (rl *Shell) commandCompletion() completion.Values {
 	line, cursor := rl.completer.Line()
 	comps := rl.Completer(*line, cursor.Pos())
 
+	if rl.Config.GetBool("colored-stats") {
+		comps.stylePaths()
+	}
+
+	return comps.convert()
+}
+
+// filenameCompletion completes the shell word before the cursor as a path.
+// The word is unquoted and unescaped, like the prefix of completion candidates.
+func (rl *Shell) filenameCompletion() completion.Values {
+	line, cursor := rl.completer.Line()
+
+	comps := CompletePaths(completion.WordPrefix(*line, cursor.Pos()))
+
+	if rl.Config.GetBool("colored-stats") {
+		comps.stylePaths()
+	}
+
 	return comps.convert()
 }
 
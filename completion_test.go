@@ -0,0 +1,42 @@
+package readline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShell_filenameCompletion(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"My Document.txt", "Documents"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "Unquoted", line: "cat " + dir + "/Doc", want: "cat " + dir + "/Documents"},
+		{name: "Double-quoted blank", line: `cat "` + dir + "/My Doc", want: `cat "` + dir + `/My Document.txt"`},
+		{name: "Escaped blank", line: "cat " + dir + `/My\ Doc`, want: "cat " + dir + `/My\ Document.txt`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rl := NewShell()
+			rl.line.Set([]rune(test.line)...)
+			rl.cursor.Set(rl.line.Len())
+
+			// The only candidate is inserted.
+			rl.completer.GenerateWith(rl.filenameCompletion)
+
+			if got := string(*rl.line); got != test.want {
+				t.Errorf("line = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
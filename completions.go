@@ -2,7 +2,13 @@ package readline
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/reeflective/readline/internal/color"
 	"github.com/reeflective/readline/internal/completion"
 )
 
@@ -23,6 +29,8 @@ type Completions struct {
 	listSep  map[string]string
 	pad      map[string]bool
 	escapes  map[string]bool
+	paths    map[string]string
+	preview  func(comp Completion) string
 
 	// Initially this will be set to the part of the current word
 	// from the beginning of the word up to the position of the cursor.
@@ -120,6 +128,63 @@ func CompleteMessage(msg string, args ...any) Completions {
 	return comps
 }
 
+// CompletePaths completes the files and directories matching a (partial) path.
+// Hidden files are only listed when the last path component starts with a dot,
+// and a leading ~/ is expanded to the user home directory when reading entries.
+// When the colored-stats option is on, candidates without an explicit style are
+// colored according to the LS_COLORS environment variable (see StyleForPath).
+func CompletePaths(path string) Completions {
+	dir, base := filepath.Split(path)
+
+	readDir := dir
+	if strings.HasPrefix(readDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			readDir = filepath.Join(home, readDir[2:])
+		}
+	}
+
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return CompleteMessage(err.Error())
+	}
+
+	comps := Completions{paths: make(map[string]string)}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		display := name
+		if entry.IsDir() {
+			display += "/"
+		}
+
+		comps.values = append(comps.values, Completion{
+			Value:   dir + display,
+			Display: display,
+		})
+		comps.paths[display] = filepath.Join(readDir, name)
+	}
+
+	return comps.NoSpace('/')
+}
+
+// StyleForPath returns the style (SGR codes, without escapes) to use for
+// a file, according to its type, permissions and extension, as specified
+// by the LS_COLORS environment variable or the dircolors defaults.
+// If info is nil, the file is stat'ed without following symlinks.
+func StyleForPath(path string, info fs.FileInfo) string {
+	return lsColors().Style(path, info)
+}
+
+var lsColors = sync.OnceValue(color.LoadLSColors)
+
 // CompleteRaw directly accepts a list of prepared Completion values.
 func CompleteRaw(values []Completion) Completions {
 	return Completions{values: completion.RawValues(values)}
@@ -384,6 +449,19 @@ func (c Completions) PreserveEscapes(tags ...string) Completions {
 	return c
 }
 
+// Preview sets a function generating a (possibly multiline) preview of the currently
+// selected candidate, which is displayed in a pane below the completions menu. The pane
+// is cropped to the available terminal space, and can be scrolled when it overflows.
+//
+//	CompleteValues("main.go", "go.mod").Preview(func(comp Completion) string {
+//		data, _ := os.ReadFile(comp.Value)
+//		return string(data)
+//	})
+func (c Completions) Preview(f func(comp Completion) string) Completions {
+	c.preview = f
+	return c
+}
+
 // Merge merges Completions (existing values are overwritten)
 //
 //	a := CompleteValues("A", "B").Invoke(c)
@@ -450,6 +528,31 @@ func (c *Completions) merge(other Completions) {
 			c.pad[tag] = other.pad[tag]
 		}
 	}
+
+	if other.preview != nil {
+		c.preview = other.preview
+	}
+
+	for display, path := range other.paths {
+		if c.paths == nil {
+			c.paths = make(map[string]string)
+		}
+
+		c.paths[display] = path
+	}
+}
+
+// stylePaths applies LS_COLORS styles to path candidates which
+// have not been given an explicit style by the completer.
+func (c *Completions) stylePaths() {
+	for index, val := range c.values {
+		path, isPath := c.paths[val.Display]
+		if !isPath || val.Style != "" {
+			continue
+		}
+
+		c.values[index].Style = StyleForPath(path, nil)
+	}
 }
 
 func (c *Completions) convert() completion.Values {
@@ -463,6 +566,7 @@ func (c *Completions) convert() completion.Values {
 	comps.ListSep = c.listSep
 	comps.Pad = c.pad
 	comps.Escapes = c.escapes
+	comps.Preview = c.preview
 
 	comps.PREFIX = c.PREFIX
 	comps.SUFFIX = c.SUFFIX
@@ -12,6 +12,7 @@ import (
 	"github.com/reeflective/readline/inputrc"
 	"github.com/reeflective/readline/internal/color"
 	"github.com/reeflective/readline/internal/completion"
+	"github.com/reeflective/readline/internal/core"
 	"github.com/reeflective/readline/internal/keymap"
 	"github.com/reeflective/readline/internal/strutil"
 	"github.com/reeflective/readline/internal/term"
@@ -125,8 +126,13 @@ func (rl *Shell) standardCommands() commands {
 		"edit-command-line":         rl.editCommandLine,
 
 		"redo":                rl.redo,
+		"undo-earlier":        rl.undoEarlier,
+		"undo-later":          rl.undoLater,
 		"select-keyword-next": rl.selectKeywordNext,
 		"select-keyword-prev": rl.selectKeywordPrev,
+
+		"add-cursor-next-match": rl.addCursorNextMatch,
+		"remove-cursors":        rl.removeCursors,
 	}
 
 	return widgets
@@ -415,17 +421,32 @@ func (rl *Shell) tabInsert() {
 
 // Insert the character typed.
 func (rl *Shell) selfInsert() {
-	rl.History.SkipSave()
-
 	// Handle suffix-autoremoval for inserted completions.
 	rl.completer.TrimSuffix()
 
 	key := rl.Keys.Caller()
 
-	// Handle autopair insertion (for the closer only)
 	searching, _, _ := rl.completer.NonIncrementallySearching()
 	isearch := rl.Keymap.Local() == keymap.Isearch
 
+	// A space expands any abbreviation before it, which
+	// must then be kept as an undo step of its own.
+	var expanded, placed bool
+
+	if !searching && !isearch && key[0] == inputrc.Space {
+		expanded, placed = rl.expandAbbreviation()
+	}
+
+	if !expanded {
+		rl.History.SkipSave()
+	}
+
+	if placed {
+		return
+	}
+
+	// Handle autopair insertion (for the closer only)
+
 	if !searching && !isearch && rl.Config.GetBool("autopairs") {
 		if jump := completion.AutopairInsertOrJump(key[0], rl.line, rl.cursor); jump {
 			return
@@ -762,24 +783,26 @@ func (rl *Shell) quoteLine() {
 // Modifies the current word under the cursor, increasing it.
 // The following word types can be incremented/decremented:
 //
+//	Dates and times (ISO-8601): the year, month, day, hour, minute or second under the cursor.
+//	Semantic versions: the component under the cursor is bumped, e.g v1.2.3 => v1.3.0.
+//	IPv4/IPv6 addresses: the octet or group under the cursor, e.g 10.0.0.255 => 10.0.1.0.
 //	Booleans: true|false, t|f, on|off, yes|no, y|n.
+//	Weekdays and month names: monday => tuesday, Jan => Feb, etc.
+//	HTTP methods: GET => POST => PUT => PATCH => DELETE => HEAD => OPTIONS.
+//	Log levels: TRACE => DEBUG => INFO => WARN => ERROR => FATAL.
 //	Operators: &&|||, ++|--, ==|!=, ===| !==, +| -, -| *, *| /, /| +, and| or.
 //	Hex digits 0xDe => 0xdf, 0xdE => 0xDF, 0xde0 => 0xddf, 0xffffffffffffffff => 0x0000000000000000.
 //	Binary digits: 0b1 => 0b10, 0B0 => 0B1, etc.
 //	Integers.
+//
+// Other keywords can be switched with the shell KeywordSwitchers.
 func (rl *Shell) keywordIncrease() {
 	rl.History.Save()
 	rl.keywordSwitch(true)
 }
 
 // Modifies the current word under the cursor, decreasing it.
-// The following word types can be incremented/decremented:
-//
-//	Booleans: true|false, t|f, on|off, yes|no, y|n.
-//	Operators: &&|||, ++|--, ==|!=, ===| !==, +| -, -| *, *| /, /| +, and| or.
-//	Hex digits 0xDe => 0xdf, 0xdE => 0xDF, 0xde0 => 0xddf, 0xffffffffffffffff => 0x0000000000000000.
-//	Binary digits: 0b1 => 0b10, 0B0 => 0B1, etc.
-//	Integers.
+// The word types that can be decreased are the same as for keyword-increase.
 func (rl *Shell) keywordDecrease() {
 	rl.History.Save()
 	rl.keywordSwitch(false)
@@ -787,47 +810,21 @@ func (rl *Shell) keywordDecrease() {
 
 // Switches the current word under the cursor, increasing or decreasing it.
 func (rl *Shell) keywordSwitch(increase bool) {
-	cpos := strutil.AdjustNumberOperatorPos(rl.cursor.Pos(), *rl.line)
-
-	// Select in word and get the selection positions
-	bpos, epos := rl.line.SelectWord(cpos)
-	epos++
-
-	// Move the cursor backward if needed/possible
-	if bpos != 0 && ((*rl.line)[bpos-1] == '+' || (*rl.line)[bpos-1] == '-') {
-		bpos--
-	}
-
-	// Get the selection string
-	selection := string((*rl.line)[bpos:epos])
+	vii := rl.Iterations.Get()
 
 	// For each of the keyword handlers, run it, which returns
 	// false/none if didn't operate, then continue to next handler.
-	for _, switcher := range strutil.KeywordSwitchers() {
-		vii := rl.Iterations.Get()
-
-		changed, word, obpos, oepos := switcher(selection, increase, vii)
-		if !changed {
-			continue
-		}
-
-		// We are only interested in the end position after all runs
-		epos = bpos + oepos
-		bpos += obpos
-
-		if cpos < bpos || cpos >= epos {
+	for _, switcher := range rl.KeywordSwitchers {
+		changed, word, bpos, epos := switcher(*rl.line, rl.cursor.Pos(), increase, vii)
+		if !changed || bpos < 0 || epos > rl.line.Len() || epos < bpos {
 			continue
 		}
 
 		// Update the line and the cursor, and return
 		// since we have a handler that has been ran.
-		begin := string((*rl.line)[:bpos])
-		end := string((*rl.line)[epos:])
-
-		newLine := append([]rune(begin), []rune(word)...)
-		newLine = append(newLine, []rune(end)...)
-		rl.line.Set(newLine...)
-		rl.cursor.Set(bpos + len(word) - 1)
+		rl.line.Cut(bpos, epos)
+		rl.line.Insert(bpos, []rune(word)...)
+		rl.cursor.Set(bpos + max(len([]rune(word))-1, 0))
 
 		return
 	}
@@ -1204,9 +1201,10 @@ func (rl *Shell) reReadInitFile() {
 // If one of the completion or non/incremental-search modes
 // are active, only cancel them and nothing else.
 func (rl *Shell) abort() {
-	// Reset any visual selection and iterations.
+	// Reset any visual selection, cursors and iterations.
 	rl.Iterations.Reset()
 	rl.selection.Reset()
+	rl.selection.ResetCursors()
 
 	// Cancel active completion insertion and/or incremental search.
 	if rl.completer.AutoCompleting() || rl.completer.IsInserting() {
@@ -1574,8 +1572,23 @@ func (rl *Shell) redo() {
 	rl.History.Redo()
 }
 
+// Restore the state of the line saved before the current one, in chronological order:
+// unlike undo, this goes through all states, including those of undone branches of changes.
+func (rl *Shell) undoEarlier() {
+	rl.History.UndoEarlier()
+}
+
+// Restore the state of the line saved after the current one, in chronological order:
+// unlike redo, this goes through all states, including those of undone branches of changes.
+func (rl *Shell) undoLater() {
+	rl.History.UndoLater()
+}
+
 // Considers the blank word under cursor, and tries a series of regular expressions on it
-// to match various patterns: URL and their various subcomponents (host/path/params, etc).
+// to match various patterns: URL and their various subcomponents (host/path/params, etc),
+// UUIDs, ARNs, email addresses, Kubernetes resources, file:line references, and those of
+// the shell KeywordMatchers. The matchers used in each keymap can be chosen with the
+// KeywordMatcherSets or with the keyword-matchers option.
 //
 // When one of the regular expressions succeeds, the match is visually selected,
 // otherwise nothing is selected (if selection was active, it will stay the same)
@@ -1590,14 +1603,16 @@ func (rl *Shell) selectKeywordNext() {
 	bpos, epos := rl.line.SelectBlankWord(rl.cursor.Pos())
 
 	// Run the regexp matchers.
-	_, epos, match := rl.selection.SelectKeyword(bpos, epos, true)
+	rl.selection.SetKeywordMatchers(rl.keywordMatchers())
+
+	_, epos, match := rl.selection.SelectKeyword(bpos, epos+1, true)
 	if !match {
 		return
 	}
 
 	// The matchers succeeded, we now have a selection active,
 	// but the cursor should be moved to the end of it.
-	rl.cursor.Set(epos)
+	rl.cursor.Set(epos - 1)
 	rl.selection.Visual(false)
 }
 
@@ -1609,13 +1624,142 @@ func (rl *Shell) selectKeywordPrev() {
 	bpos, epos := rl.line.SelectBlankWord(rl.cursor.Pos())
 
 	// Run the regexp matchers.
-	_, epos, match := rl.selection.SelectKeyword(bpos, epos, false)
+	rl.selection.SetKeywordMatchers(rl.keywordMatchers())
+
+	_, epos, match := rl.selection.SelectKeyword(bpos, epos+1, false)
 	if !match {
 		return
 	}
 
 	// The matchers succeeded, we now have a selection active,
 	// but the cursor should be moved to the end of it.
-	rl.cursor.Set(epos)
+	rl.cursor.Set(epos - 1)
 	rl.selection.Visual(false)
 }
+
+// keywordMatchers returns the keyword matchers used in the current main keymap, in order.
+func (rl *Shell) keywordMatchers() []KeywordMatcher {
+	keymap := string(rl.Keymap.Main())
+
+	names, set := rl.KeywordMatcherSets[keymap]
+	if !set {
+		names, set = keywordMatcherNames(rl.Config.GetString("keyword-matchers"), keymap)
+	}
+
+	if !set {
+		return rl.KeywordMatchers
+	}
+
+	matchers := make([]KeywordMatcher, 0, len(names))
+
+	for _, name := range names {
+		for _, matcher := range rl.KeywordMatchers {
+			if matcher.Name == name {
+				matchers = append(matchers, matcher)
+			}
+		}
+	}
+
+	return matchers
+}
+
+// keywordMatcherNames returns the names of the keyword matchers to use in a keymap, from
+// the keyword-matchers option: it contains lists of comma-separated names, separated by
+// spaces, each prefixed with a keymap and a colon, except for the default list.
+// Ex: "uri,email vi-command:kubernetes,uuid".
+func keywordMatcherNames(option, keymap string) (names []string, set bool) {
+	for _, entry := range strings.Fields(strings.Trim(option, "\"")) {
+		entryKeymap, list, found := strings.Cut(entry, ":")
+
+		switch {
+		case found && entryKeymap == keymap:
+			return strings.Split(list, ","), true
+		case !found && !set:
+			names, set = strings.Split(entry, ","), true
+		}
+	}
+
+	return names, set
+}
+
+//
+// Multiple cursors ------------------------------------------------------------
+//
+
+// Add a cursor at the next occurrence of the word under cursor (or the word just before it),
+// after the last cursor added, and wrapping around the line. The text inserted and deleted
+// at the main cursor is then also inserted and deleted at all cursors, and word and character
+// motions are applied to all of them. Cursors are removed when leaving insert mode or aborting.
+func (rl *Shell) addCursorNextMatch() {
+	rl.History.SkipSave()
+
+	vii := rl.Iterations.Get()
+	for i := 1; i <= vii; i++ {
+		if !rl.selection.AddCursorNextMatch() {
+			return
+		}
+	}
+}
+
+// Remove all cursors but the main one.
+func (rl *Shell) removeCursors() {
+	rl.History.SkipSave()
+	rl.selection.ResetCursors()
+}
+
+// cursorMotions returns the motions that are also applied to all cursors when they
+// are run at the main one. They don't include autosuggestion insertions, if any.
+func (rl *Shell) cursorMotions() map[string]func() {
+	forward := func(tokenizer core.Tokenizer) func() {
+		return func() { rl.cursor.Move(rl.line.Forward(tokenizer, rl.cursor.Pos())) }
+	}
+
+	backward := func(tokenizer core.Tokenizer) func() {
+		return func() { rl.cursor.Move(rl.line.Backward(tokenizer, rl.cursor.Pos())) }
+	}
+
+	forwardEnd := func(tokenizer core.Tokenizer) func() {
+		return func() { rl.cursor.Move(rl.line.ForwardEnd(tokenizer, rl.cursor.Pos())) }
+	}
+
+	return map[string]func(){
+		"forward-char":        rl.cursor.Inc,
+		"backward-char":       rl.cursor.Dec,
+		"vi-forward-char":     rl.cursor.Inc,
+		"vi-backward-char":    rl.cursor.Dec,
+		"vi-append-mode":      rl.cursor.Inc,
+		"forward-word":        func() { rl.cursor.Move(rl.line.ForwardEnd(rl.line.Tokenize, rl.cursor.Pos()) + 1) },
+		"backward-word":       backward(rl.line.Tokenize),
+		"vi-forward-word":     forward(rl.line.Tokenize),
+		"vi-next-word":        forward(rl.line.Tokenize),
+		"vi-backward-word":    backward(rl.line.Tokenize),
+		"vi-prev-word":        backward(rl.line.Tokenize),
+		"vi-end-word":         forwardEnd(rl.line.Tokenize),
+		"vi-forward-bigword":  forward(rl.line.TokenizeSpace),
+		"vi-backward-bigword": backward(rl.line.TokenizeSpace),
+		"vi-end-bigword":      forwardEnd(rl.line.TokenizeSpace),
+	}
+}
+
+// updateCursors applies the last command to all cursors: if it is one of the cursor
+// motions, it is run at each of them (as many times as the main one did), otherwise
+// any edit done at the main cursor is replicated at all of them.
+func (rl *Shell) updateCursors(bind inputrc.Bind, iterations core.Iterations, motion bool) {
+	if len(rl.selection.Cursors()) == 0 {
+		return
+	}
+
+	move, found := rl.cursorMotions()[bind.Action]
+	if !motion || !found {
+		rl.selection.UpdateCursors()
+		return
+	}
+
+	times := iterations.Get()
+
+	rl.selection.MoveCursors(func() {
+		for i := 1; i <= times; i++ {
+			move()
+		}
+	})
+}
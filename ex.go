@@ -0,0 +1,233 @@
+package readline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/editor"
+)
+
+// Open the ex command line, in which a subset of Vim ex commands can be run on the
+// input line, such as :s/pattern/replacement/, :normal, :registers, :marks, :set or :noh.
+// When a selection is active, the command line starts with the range of its lines.
+func (rl *Shell) viExCommand() {
+	rl.History.SkipSave()
+
+	var cmdline string
+
+	if rl.selection.Active() {
+		bpos, epos := rl.selection.Pos()
+		start := strings.Count(string((*rl.line)[:bpos]), "\n")
+		end := start + strings.Count(string((*rl.line)[bpos:epos]), "\n")
+		cmdline = fmt.Sprintf("%d,%d", start+1, end+1)
+
+		rl.selection.Reset()
+		rl.Keymap.SetLocal("")
+	}
+
+	rl.ex.ResetWalk()
+	rl.completer.ExStart(cmdline)
+}
+
+// exCommands returns the commands replacing some bound commands
+// when editing the ex command line: completion completes ex
+// commands, and history commands walk the ex command history.
+func (rl *Shell) exCommands() commands {
+	previous := func() { rl.exWalk(false) }
+	next := func() { rl.exWalk(true) }
+
+	return map[string]func(){
+		"vi-movement-mode":        rl.completer.NonIsearchStop,
+		"complete":                rl.exComplete,
+		"menu-complete":           rl.exComplete,
+		"expand-or-complete":      rl.exComplete,
+		"previous-history":        previous,
+		"up-history":              previous,
+		"up-line-or-history":      previous,
+		"up-line-or-search":       previous,
+		"history-search-backward": previous,
+		"next-history":            next,
+		"down-history":            next,
+		"down-line-or-history":    next,
+		"down-line-or-search":     next,
+		"history-search-forward":  next,
+		"menu-select":             next,
+	}
+}
+
+// acceptExCommand closes the ex command line, and runs it on the input line.
+func (rl *Shell) acceptExCommand() {
+	cmdline, _, _ := rl.completer.GetBuffer()
+	rl.completer.NonIsearchStop()
+
+	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
+	rl.ex.Add(string(*cmdline))
+
+	if err := rl.runExCommand(string(*cmdline)); err != nil {
+		rl.Hint.SetTemporary(color.FgRed + err.Error() + color.Reset)
+	}
+}
+
+// runExCommand parses and runs an ex command line.
+func (rl *Shell) runExCommand(cmdline string) error {
+	if strings.TrimLeft(cmdline, ": \t") == "" {
+		return nil
+	}
+
+	cmd, err := editor.ParseEx(cmdline, rl.cursor.LinePos(), rl.line.Lines())
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, cmdline)
+	}
+
+	switch cmd.Name {
+	case "substitute":
+		line, pos, err := rl.ex.Substitute(string(*rl.line), cmd.Start, cmd.End, cmd.Args)
+		if err != nil {
+			return err
+		}
+
+		rl.line.Set([]rune(line)...)
+		rl.cursor.Set(pos)
+
+	case "normal":
+		if cmd.Ranged {
+			return editor.ErrExNoRange
+		}
+
+		if cmd.Args == "" {
+			return editor.ErrExArgument
+		}
+
+		rl.Keys.Feed(false, []rune(inputrc.Unescape(cmd.Args))...)
+
+	case "registers", "display":
+		rl.viRegistersComplete()
+
+	case "marks":
+		rl.Hint.SetTemporary(rl.exMarks())
+
+	case "nohlsearch":
+		rl.selection.SetSearch(nil)
+
+	case "set":
+		return rl.exSet(cmd.Args)
+	}
+
+	return nil
+}
+
+// exSet sets, toggles or displays inputrc variables, like the Vim :set command:
+// name=value sets a variable, name sets a boolean and displays the others, noname
+// unsets a boolean, invname or name! toggles it, and name? displays any variable.
+func (rl *Shell) exSet(args string) error {
+	if args == "" {
+		return editor.ErrExArgument
+	}
+
+	var shown []string
+
+	for _, arg := range strings.Fields(args) {
+		name, value, assign := strings.Cut(arg, "=")
+		query := strings.HasSuffix(name, "?")
+		toggle := strings.HasSuffix(name, "!")
+		name = strings.TrimRight(name, "?!")
+
+		// Boolean variables can be prefixed with no (unset) or inv (toggle).
+		if unset, isBool := rl.boolVar(name, "no"); isBool && !assign {
+			name, value, assign = unset, "off", true
+		} else if inverted, isBool := rl.boolVar(name, "inv"); isBool && !assign {
+			name, toggle = inverted, true
+		}
+
+		current := rl.Config.Get(name)
+		enabled, isBool := current.(bool)
+
+		switch {
+		case current == nil:
+			return fmt.Errorf("%w: %s", editor.ErrExOption, arg)
+		case toggle && isBool && enabled:
+			value, assign = "off", true
+		case toggle && isBool, !assign && !query && isBool:
+			value, assign = "on", true
+		}
+
+		if !assign {
+			shown = append(shown, fmt.Sprintf("%s=%v", name, rl.Config.Get(name)))
+			continue
+		}
+
+		// Values are converted like in inputrc files.
+		if err := inputrc.ParseBytes([]byte("set "+name+" "+value), rl.Config, rl.Opts...); err != nil {
+			return err
+		}
+	}
+
+	if len(shown) > 0 {
+		rl.Hint.SetTemporary(strings.Join(shown, "  "))
+	}
+
+	return nil
+}
+
+// boolVar returns the name of a boolean inputrc variable
+// from its name with a prefix, if the prefixed name is not
+// a variable itself.
+func (rl *Shell) boolVar(name, prefix string) (string, bool) {
+	if !strings.HasPrefix(name, prefix) || rl.Config.Get(name) != nil {
+		return name, false
+	}
+
+	_, isBool := rl.Config.Get(strings.TrimPrefix(name, prefix)).(bool)
+
+	return strings.TrimPrefix(name, prefix), isBool
+}
+
+// exMarks returns the marks set in the input line, with their position and line.
+func (rl *Shell) exMarks() string {
+	lines := strings.Split(string(*rl.line), "\n")
+	text := color.Dim + "mark line  col text" + color.Reset
+
+	for _, name := range append(rl.marks.Names(), '^') {
+		pos, set := rl.marks.Get(name)
+		if name == '^' {
+			pos, set = rl.cursor.Mark(), rl.cursor.Mark() != -1
+		}
+
+		if !set || pos > rl.line.Len() {
+			continue
+		}
+
+		row := strings.Count(string((*rl.line)[:pos]), "\n")
+		col := pos - len([]rune(strings.Join(lines[:row], "\n"))) - min(row, 1)
+
+		text += fmt.Sprintf("\n %-3c %4d %4d %s", name, row+1, col, lines[row])
+	}
+
+	return text
+}
+
+// exComplete completes the command name or the :set variable being
+// typed in the ex command line, cycling through candidates.
+func (rl *Shell) exComplete() {
+	vars := make([]string, 0, len(rl.Config.Vars))
+	for name := range rl.Config.Vars {
+		vars = append(vars, name)
+	}
+
+	sort.Strings(vars)
+
+	cmdline := rl.ex.Complete(string(*rl.line), vars)
+	rl.line.Set([]rune(cmdline)...)
+	rl.cursor.Set(rl.line.Len())
+}
+
+// exWalk replaces the ex command line with the previous (or next) one
+// in the ex command history starting with the command line typed.
+func (rl *Shell) exWalk(forward bool) {
+	cmdline := rl.ex.Walk(string(*rl.line), forward)
+	rl.line.Set([]rune(cmdline)...)
+	rl.cursor.Set(rl.line.Len())
+}
@@ -8,4 +8,7 @@ require (
 	golang.org/x/term v0.8.0
 )
 
-require github.com/rivo/uniseg v0.4.4
+require (
+	github.com/rivo/uniseg v0.4.4
+	gopkg.in/yaml.v3 v3.0.1
+)
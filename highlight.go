@@ -0,0 +1,254 @@
+package readline
+
+import (
+	"strings"
+
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// Kinds of shell syntax elements, used as keys in highlighter style maps.
+const (
+	HighlightCommand        = "command"         // A command found valid.
+	HighlightUnknownCommand = "unknown-command" // A command not found valid.
+	HighlightArgument       = "argument"        // An unquoted argument.
+	HighlightFlag           = "flag"            // An argument starting with a dash.
+	HighlightString         = "string"          // A single or double-quoted string.
+	HighlightVariable       = "variable"        // A parameter expansion, or a variable assignment.
+	HighlightSubshell       = "subshell"        // A command substitution.
+	HighlightOperator       = "operator"        // A control operator (pipes, lists, etc).
+	HighlightRedirection    = "redirection"     // A redirection operator.
+	HighlightComment        = "comment"         // A comment, up to the end of the line.
+)
+
+// DefaultHighlightStyles are the styles used by shell highlighters
+// for syntax elements without a style in their own style map.
+var DefaultHighlightStyles = map[string]string{
+	HighlightCommand:        color.FgGreen,
+	HighlightUnknownCommand: color.FgRed,
+	HighlightArgument:       "",
+	HighlightFlag:           color.FgCyan,
+	HighlightString:         color.FgYellow,
+	HighlightVariable:       color.FgMagenta,
+	HighlightSubshell:       color.FgBlue,
+	HighlightOperator:       color.Bold,
+	HighlightRedirection:    color.FgBlueBright,
+	HighlightComment:        color.SGRStart + color.Fg + "244" + color.SGREnd,
+}
+
+// ShellHighlighter is a fish-like syntax highlighter for shell command lines: commands
+// are highlighted according to their validity, and arguments, flags, strings, variables,
+// comments and operators have their own styles. It can be used either as the shell span
+// highlighter (recommended), or as its syntax highlighter:
+//
+//	highlighter := readline.NewShellHighlighter(isCommand, nil)
+//	shell.SpanHighlighter = highlighter.Spans
+type ShellHighlighter struct {
+	isCommand func(cmd string) bool
+	styles    map[string]string
+	commands  map[string]bool
+}
+
+// NewShellHighlighter returns a shell highlighter using a function to check if commands
+// are valid (if nil, all commands are), and a map of styles for syntax elements, keyed by
+// their kind (see the Highlight* constants). Elements not found in the map are styled
+// with DefaultHighlightStyles.
+//
+// Command checks are cached, so that the highlighter is cheap enough to run on each refresh,
+// even with slow checks. The cache is cleared when the input line is cleared (as when a new
+// line is read), and with ResetCommands when the set of valid commands changes.
+func NewShellHighlighter(isCommand func(cmd string) bool, styles map[string]string) *ShellHighlighter {
+	return &ShellHighlighter{
+		isCommand: isCommand,
+		styles:    styles,
+		commands:  make(map[string]bool),
+	}
+}
+
+// Spans returns the styled spans of the line, to be used as Shell.SpanHighlighter.
+func (h *ShellHighlighter) Spans(line []rune) []Span {
+	if len(line) == 0 {
+		h.ResetCommands()
+		return nil
+	}
+
+	tokens := strutil.Lex(string(line))
+	spans := make([]Span, 0, len(tokens))
+	commandPos := true
+
+	for first := 0; first < len(tokens); first++ {
+		tok := tokens[first]
+
+		switch tok.Kind {
+		case strutil.TokenOperator:
+			spans = h.add(spans, tok.Runes, HighlightOperator)
+			commandPos = true
+
+			continue
+		case strutil.TokenRedirection:
+			spans = h.add(spans, tok.Runes, HighlightRedirection)
+
+			// The redirection target is not a command.
+			if first+1 < len(tokens) && tokens[first+1].IsWord() {
+				last := wordEnd(tokens, first+1)
+				for _, target := range tokens[first+1 : last+1] {
+					spans = h.add(spans, target.Runes, h.wordKind(target, false))
+				}
+
+				first = last
+			}
+
+			continue
+		case strutil.TokenComment:
+			spans = h.add(spans, tok.Runes, HighlightComment)
+			continue
+		}
+
+		// All tokens of this word.
+		last := wordEnd(tokens, first)
+		word := tokens[first : last+1]
+		first = last
+
+		switch {
+		case commandPos && isAssignment(word):
+			name := strutil.Span{Start: word[0].Runes.Start}
+			name.End = name.Start + strings.Index(word[0].Text, "=")
+			spans = h.add(spans, name, HighlightVariable)
+
+		case commandPos:
+			kind := HighlightUnknownCommand
+			if h.valid(wordValue(word)) {
+				kind = HighlightCommand
+			}
+
+			command := strutil.Span{Start: word[0].Runes.Start, End: word[len(word)-1].Runes.End}
+			spans = h.add(spans, command, kind)
+			commandPos = false
+
+		default:
+			for i, part := range word {
+				spans = h.add(spans, part.Runes, h.wordKind(part, i == 0))
+			}
+		}
+	}
+
+	return spans
+}
+
+// ResetCommands clears the cache of command checks. It should be called when the set of
+// valid commands changes (like when a program is installed or an alias defined), so that
+// commands already checked are checked again.
+func (h *ShellHighlighter) ResetCommands() {
+	clear(h.commands)
+}
+
+// Highlight returns the line with its syntax highlighted, to be used as Shell.SyntaxHighlighter.
+func (h *ShellHighlighter) Highlight(line []rune) string {
+	var (
+		highlighted strings.Builder
+		pos         int
+	)
+
+	for _, span := range h.Spans(line) {
+		highlighted.WriteString(string(line[pos:span.Start]))
+		highlighted.WriteString(span.Style + string(line[span.Start:span.End]) + color.Reset)
+		pos = span.End
+	}
+
+	highlighted.WriteString(string(line[pos:]))
+
+	return highlighted.String()
+}
+
+// add appends a span styled for a syntax element, if it has a style.
+func (h *ShellHighlighter) add(spans []Span, span strutil.Span, kind string) []Span {
+	style, found := h.styles[kind]
+	if !found {
+		style = DefaultHighlightStyles[kind]
+	}
+
+	if style == "" || span.End <= span.Start {
+		return spans
+	}
+
+	return append(spans, Span{Start: span.Start, End: span.End, Style: style})
+}
+
+// valid returns true if the command is valid, caching the result.
+func (h *ShellHighlighter) valid(cmd string) bool {
+	if h.isCommand == nil {
+		return true
+	}
+
+	valid, found := h.commands[cmd]
+	if !found {
+		valid = h.isCommand(cmd)
+		h.commands[cmd] = valid
+	}
+
+	return valid
+}
+
+// wordKind returns the syntax element kind of a word token in argument position.
+func (h *ShellHighlighter) wordKind(tok strutil.Token, start bool) string {
+	switch tok.Kind {
+	case strutil.TokenQuoted:
+		return HighlightString
+	case strutil.TokenVariable:
+		return HighlightVariable
+	case strutil.TokenSubshell:
+		return HighlightSubshell
+	}
+
+	if start && strings.HasPrefix(tok.Text, "-") {
+		return HighlightFlag
+	}
+
+	return HighlightArgument
+}
+
+// wordEnd returns the index of the last token of the word starting at first.
+func wordEnd(tokens []strutil.Token, first int) (last int) {
+	last = first
+	for last+1 < len(tokens) && tokens[last+1].Joined {
+		last++
+	}
+
+	return last
+}
+
+// isAssignment returns true if the word is a variable assignment (NAME=value).
+func isAssignment(word []strutil.Token) bool {
+	if word[0].Kind != strutil.TokenWord {
+		return false
+	}
+
+	name, _, found := strings.Cut(word[0].Text, "=")
+	if !found || name == "" {
+		return false
+	}
+
+	for i, char := range name {
+		if (i == 0 && !strutil.IsNameStart(char)) || !strutil.IsNameChar(char) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// wordValue returns the (unquoted) value of a word made of several tokens.
+func wordValue(word []strutil.Token) string {
+	var value string
+
+	for _, tok := range word {
+		switch tok.Kind {
+		case strutil.TokenWord, strutil.TokenQuoted:
+			value += tok.Value
+		default:
+			value += tok.Text
+		}
+	}
+
+	return value
+}
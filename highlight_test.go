@@ -0,0 +1,93 @@
+package readline
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShellHighlighter_Spans(t *testing.T) {
+	commands := []string{"cat", "echo", "grep", "ls"}
+
+	// Each syntax element is styled with its kind, for readability.
+	styles := make(map[string]string)
+	for kind := range DefaultHighlightStyles {
+		styles[kind] = kind
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "Empty line", line: ""},
+		{name: "Command and flag", line: "ls -la", want: []string{"ls:command", "-la:flag"}},
+		{name: "Unknown command", line: "foo bar", want: []string{"foo:unknown-command", "bar:argument"}},
+		{name: "Quoted command", line: `"ls" -l`, want: []string{`"ls":command`, "-l:flag"}},
+		{
+			name: "Quotes", line: `echo "hi there" 'x'`,
+			want: []string{"echo:command", `"hi there":string`, "'x':string"},
+		},
+		{name: "Comment", line: "ls # a comment", want: []string{"ls:command", "# a comment:comment"}},
+		{name: "Not a comment", line: "echo a#b", want: []string{"echo:command", "a#b:argument"}},
+		{
+			name: "Redirections", line: "cat < in > out",
+			want: []string{"cat:command", "<:redirection", "in:argument", ">:redirection", "out:argument"},
+		},
+		{
+			name: "Operators", line: "ls | grep x && foo",
+			want: []string{"ls:command", "|:operator", "grep:command", "x:argument", "&&:operator", "foo:unknown-command"},
+		},
+		{name: "Assignment", line: "FOO=1 ls", want: []string{"FOO:variable", "ls:command"}},
+		{name: "Assignment with digits", line: "_F1=1 ls", want: []string{"_F1:variable", "ls:command"}},
+		{name: "Not an assignment", line: "1F=1 ls", want: []string{"1F=1:unknown-command", "ls:argument"}},
+		{
+			name: "Expansions", line: "echo $HOME $(ls)",
+			want: []string{"echo:command", "$HOME:variable", "$(ls):subshell"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			highlighter := NewShellHighlighter(func(cmd string) bool { return slices.Contains(commands, cmd) }, styles)
+			line := []rune(test.line)
+
+			var got []string
+			for _, span := range highlighter.Spans(line) {
+				got = append(got, string(line[span.Start:span.End])+":"+span.Style)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("ShellHighlighter.Spans() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestShellHighlighter_ResetCommands(t *testing.T) {
+	installed := false
+	checks := 0
+
+	highlighter := NewShellHighlighter(func(string) bool {
+		checks++
+		return installed
+	}, nil)
+
+	line := []rune("tool")
+	style := func() string { return highlighter.Spans(line)[0].Style }
+
+	if style() != DefaultHighlightStyles[HighlightUnknownCommand] {
+		t.Fatalf("ShellHighlighter.Spans() style = %q, want unknown command", style())
+	}
+
+	// The check is cached until the commands are reset.
+	installed = true
+	if got := style(); got != DefaultHighlightStyles[HighlightUnknownCommand] || checks != 1 {
+		t.Errorf("ShellHighlighter.Spans() style = %q after %d checks, want cached unknown command", got, checks)
+	}
+
+	highlighter.ResetCommands()
+
+	if got := style(); got != DefaultHighlightStyles[HighlightCommand] || checks != 2 {
+		t.Errorf("ShellHighlighter.Spans() style = %q after %d checks, want command", got, checks)
+	}
+}
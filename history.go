@@ -1,9 +1,14 @@
 package readline
 
 import (
+	"errors"
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/completion"
 	"github.com/reeflective/readline/internal/history"
 	"github.com/reeflective/readline/internal/strutil"
 )
@@ -53,6 +58,7 @@ func (rl *Shell) historyCommands() commands {
 		"insert-last-argument":                   rl.yankLastArg,
 		"yank-nth-arg":                           rl.yankNthArg,
 		"magic-space":                            rl.magicSpace,
+		"history-expand-line":                    rl.historyExpandLine,
 
 		"accept-and-hold":                    rl.acceptAndHold,
 		"accept-and-infer-next-history":      rl.acceptAndInferNextHistory,
@@ -322,48 +328,56 @@ func (rl *Shell) yankNthArg() {
 }
 
 // Perform history expansion on the current line and insert a space.
-// If the current blank word under cursor starts with an exclamation
-// mark, the word up to the cursor is matched as a prefix against
-// the history lines, and the first match is inserted in place of it.
+// The line up to the cursor is expanded with csh-style history expansion
+// (!!, !$, !n, !-n, !string, ^old^new, word designators and modifiers).
+// Abbreviations, if any, are expanded instead when found before the cursor.
 func (rl *Shell) magicSpace() {
-	cpos := rl.cursor.Pos()
-	lineLen := rl.line.Len()
+	// Abbreviations are expanded first, and followed by a space
+	// unless the expansion places the cursor somewhere in it.
+	if expanded, placed := rl.expandAbbreviation(); expanded {
+		if !placed {
+			rl.cursor.InsertAt(inputrc.Space)
+		}
 
-	// If no line, or the cursor is on a space, we can't perform.
-	if lineLen == 0 || (cpos == lineLen && (*rl.line)[cpos-1] == inputrc.Space) {
-		rl.selfInsert()
 		return
 	}
 
-	// Select the word around cursor.
-	rl.viSelectInBlankWord()
-	word, bpos, _, _ := rl.selection.Pop()
-	rl.cursor.Set(cpos)
+	cpos := rl.cursor.Pos()
+	line := string((*rl.line)[:cpos])
 
-	// Fail if empty or not prefixed expandable.
-	if len(strings.TrimSpace(word)) == 0 {
-		rl.selfInsert()
-		return
+	expanded, _, err := rl.History.Expand(line)
+	if err != nil {
+		rl.Hint.SetTemporary(color.FgRed + err.Error() + color.Reset)
 	}
 
-	if !strings.HasPrefix(word, "!") || word == "!" {
+	if err != nil || expanded == line {
 		rl.selfInsert()
 		return
 	}
 
-	// Else, perform expansion on the remainder.
-	pattern := (*rl.line)[bpos+1:]
-	suggested := rl.History.Suggest(&pattern)
+	rl.History.Save()
+	rl.line.Cut(0, cpos)
+	rl.line.Insert(0, []rune(expanded)...)
+	rl.cursor.Set(len([]rune(expanded)))
+	rl.cursor.InsertAt(inputrc.Space)
+}
 
-	if string(suggested) == string(pattern) {
-		rl.selfInsert()
+// Perform history expansion on the current line.
+func (rl *Shell) historyExpandLine() {
+	rl.History.Save()
+
+	line := string(*rl.line)
+
+	expanded, _, err := rl.History.Expand(line)
+	if err != nil {
+		rl.Hint.SetTemporary(color.FgRed + err.Error() + color.Reset)
 		return
 	}
 
-	rl.History.Save()
-	rl.line.Cut(bpos, lineLen)
-	rl.line.Insert(bpos, suggested...)
-	rl.cursor.Set(bpos + suggested.Len())
+	if expanded != line {
+		rl.line.Set([]rune(expanded)...)
+		rl.cursor.Set(rl.line.Len())
+	}
 }
 
 //
@@ -464,6 +478,7 @@ func (rl *Shell) inferNextHistory() {
 func (rl *Shell) beginningOfBufferOrHistory() {
 	if rl.cursor.Pos() > 0 {
 		rl.History.SkipSave()
+		rl.marks.Jump(rl.cursor.Pos())
 		rl.cursor.Set(0)
 
 		return
@@ -478,6 +493,7 @@ func (rl *Shell) beginningOfBufferOrHistory() {
 func (rl *Shell) endOfBufferOrHistory() {
 	if rl.cursor.Pos() < rl.line.Len()-1 {
 		rl.History.SkipSave()
+		rl.marks.Jump(rl.cursor.Pos())
 		rl.cursor.Set(rl.line.Len())
 
 		return
@@ -634,15 +650,26 @@ func (rl *Shell) acceptLineWith(infer, hold bool) {
 	// function on (with) the input line itself, not the minibuffer.
 	rl.completer.Reset()
 
+	// The ex command line is run on the input line instead.
+	if rl.completer.IsExCommandLine() {
+		rl.acceptExCommand()
+		return
+	}
+
 	// Non-incremental search modes are the only mode not cancelled
 	// by the completion engine. If it's active, match the line result
 	// and return without returning the line to the readline caller.
 	searching, forward, substring := rl.completer.NonIncrementallySearching()
 	if searching {
-		defer rl.completer.NonIsearchStop()
-
 		line, cursor, _ := rl.completer.GetBuffer()
 		rl.History.InsertMatch(line, cursor, true, forward, substring)
+		rl.completer.NonIsearchStop()
+
+		// Vim searches highlight their matches in the line.
+		if !rl.Keymap.IsEmacs() {
+			rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
+			rl.viHighlightSearch(string(*line), forward, false)
+		}
 
 		return
 	}
@@ -650,19 +677,31 @@ func (rl *Shell) acceptLineWith(infer, hold bool) {
 	// Use the correct buffer for the rest of the function.
 	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
 
-	// Without multiline support, we always return the line.
-	if rl.AcceptMultiline == nil {
-		rl.Macros.StopRecord(rl.Keys.Caller()...)
-
-		rl.Display.AcceptLine()
-		rl.History.Accept(hold, infer, nil)
+	// Expand any abbreviation before the cursor,
+	// and history events if asked to do so.
+	rl.expandAbbreviation()
 
+	if rl.Config.GetBool("history-expand-on-accept") && !rl.expandHistoryOnAccept() {
 		return
 	}
 
-	// Ask the caller if the line should be accepted
+	// Use the builtin shell syntax check if enabled
+	// and if the caller does not provide its own.
+	acceptMultiline := rl.AcceptMultiline
+	if acceptMultiline == nil && rl.Config.GetBool("multiline-syntax") {
+		acceptMultiline = func(line []rune) bool {
+			return strutil.Continuation(string(line)) == ""
+		}
+	}
+
+	// Without multiline support, we always return the line.
+	// Otherwise ask the caller if the line should be accepted
 	// as is, save the command line and accept it.
-	if rl.AcceptMultiline(*rl.line) {
+	if acceptMultiline == nil || acceptMultiline(*rl.line) {
+		if !rl.correctCommand() || !rl.validateLine() {
+			return
+		}
+
 		rl.Macros.StopRecord(rl.Keys.Caller()...)
 
 		rl.Display.AcceptLine()
@@ -679,6 +718,163 @@ func (rl *Shell) acceptLineWith(infer, hold bool) {
 	rl.cursor.Inc()
 }
 
+// expandHistoryOnAccept performs history expansion on the line about to be accepted.
+// If expansion fails, the error is displayed in the hint section, and if the :p modifier
+// is used, the line is expanded but not accepted: in both cases, false is returned.
+func (rl *Shell) expandHistoryOnAccept() bool {
+	line := string(*rl.line)
+
+	expanded, print, err := rl.History.Expand(line)
+	if err != nil {
+		rl.Hint.SetTemporary(color.FgRed + err.Error() + color.Reset)
+		return false
+	}
+
+	if expanded != line {
+		rl.History.Save()
+		rl.line.Set([]rune(expanded)...)
+		rl.cursor.Set(rl.line.Len())
+	}
+
+	return !print
+}
+
+// correctCommand proposes a correction for the first word of the line, if it is not a
+// valid command, and reads the answer. It returns false if the line must not be accepted.
+func (rl *Shell) correctCommand() bool {
+	if rl.CorrectCommands == nil {
+		return true
+	}
+
+	tokens := strutil.Lex(string(*rl.line))
+	if len(tokens) == 0 || tokens[0].Kind != strutil.TokenWord || tokens[0].Text != tokens[0].Value {
+		return true
+	}
+
+	word := tokens[0]
+	commands := rl.CorrectCommands()
+
+	for _, cmd := range commands {
+		if cmd == word.Text {
+			return true
+		}
+	}
+
+	correction, found := completion.Closest(word.Text, commands, rl.Config.GetInt("correct-max-errors"))
+	if !found {
+		return true
+	}
+
+	done := rl.Keymap.PendingCursor()
+	defer done()
+
+	rl.Hint.Set(fmt.Sprintf("correct %s%s%s to %s%s%s? [nyae]",
+		color.FgRed, word.Text, color.Reset, color.FgGreen, correction, color.Reset))
+	rl.Display.Refresh()
+
+	key, isAbort := rl.Keys.ReadKey()
+	rl.Hint.Reset()
+
+	switch {
+	case isAbort || key == 'e':
+		rl.cursor.Set(word.Runes.End)
+		return false
+
+	case key == 'a':
+		rl.History.Save()
+		rl.line.Set()
+		rl.cursor.Set(0)
+
+		return false
+
+	case key == 'y':
+		rl.History.Save()
+		rl.line.Cut(word.Runes.Start, word.Runes.End)
+		rl.line.Insert(word.Runes.Start, []rune(correction)...)
+		rl.cursor.Set(rl.line.Len())
+	}
+
+	return true
+}
+
+// validateLine runs the user-provided validator on the line, if any. If the line is
+// invalid, the error is displayed in the hint section, the cursor is moved to the
+// error position if it carries one, and false is returned.
+func (rl *Shell) validateLine() bool {
+	if rl.Validator == nil {
+		return true
+	}
+
+	err := rl.Validator(*rl.line)
+	if err == nil {
+		return true
+	}
+
+	rl.Hint.SetTemporary(color.FgRed + err.Error() + color.Reset)
+
+	var validation *ValidationError
+	if errors.As(err, &validation) && validation.HasPos {
+		rl.cursor.Set(validation.Pos)
+	}
+
+	return false
+}
+
+// expandAbbreviation replaces the abbreviation just before the cursor with its expansion,
+// as a single undo step. By default, only abbreviations used as commands are expanded.
+// If the expansion contains the cursor marker, the cursor is placed there and placed is true.
+func (rl *Shell) expandAbbreviation() (expanded, placed bool) {
+	if len(rl.Abbreviations) == 0 {
+		return false, false
+	}
+
+	cpos := rl.cursor.Pos()
+	tokens := strutil.Lex(string(*rl.line))
+
+	// The abbreviation must be a whole unquoted word ending at the cursor.
+	first, last, found := strutil.WordAt(tokens, cpos)
+	if !found || first != last || tokens[first].Runes.End != cpos {
+		return false, false
+	}
+
+	word := tokens[first]
+	if word.Kind != strutil.TokenWord || word.Text != word.Value {
+		return false, false
+	}
+
+	commandPos := first == 0 || tokens[first-1].Kind == strutil.TokenOperator
+	if !commandPos && !rl.Config.GetBool("abbreviations-anywhere") {
+		return false, false
+	}
+
+	expansion, found := rl.Abbreviations[word.Text]
+	if !found {
+		return false, false
+	}
+
+	// Find and remove the cursor marker, if any.
+	offset := -1
+	marker := rl.Config.GetString("abbreviation-cursor-marker")
+
+	if idx := strings.Index(expansion, marker); marker != "" && idx >= 0 {
+		offset = utf8.RuneCountInString(expansion[:idx])
+		expansion = expansion[:idx] + expansion[idx+len(marker):]
+	}
+
+	rl.History.Save()
+
+	rl.line.Cut(word.Runes.Start, cpos)
+	rl.line.Insert(word.Runes.Start, []rune(expansion)...)
+
+	if offset >= 0 {
+		rl.cursor.Set(word.Runes.Start + offset)
+	} else {
+		rl.cursor.Set(word.Runes.Start + utf8.RuneCountInString(expansion))
+	}
+
+	return true, offset >= 0
+}
+
 func (rl *Shell) insertAutosuggestPartial(emacs bool) {
 	cpos := rl.cursor.Pos()
 	if cpos < rl.line.Len()-1 {
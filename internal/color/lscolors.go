@@ -0,0 +1,177 @@
+package color
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultLSColors is a subset of the GNU dircolors default database,
+// used when the LS_COLORS environment variable is not set.
+var defaultLSColors = "rs=0:di=01;34:ln=01;36:mh=00:pi=40;33:so=01;35:do=01;35:" +
+	"bd=40;33;01:cd=40;33;01:or=40;31;01:mi=00:su=37;41:sg=30;43:ca=00:" +
+	"tw=30;42:ow=34;42:st=37;44:ex=01;32:" +
+	"*.tar=01;31:*.tgz=01;31:*.zip=01;31:*.gz=01;31:*.bz2=01;31:*.xz=01;31:" +
+	"*.zst=01;31:*.7z=01;31:*.rar=01;31:*.deb=01;31:*.rpm=01;31:" +
+	"*.jpg=01;35:*.jpeg=01;35:*.gif=01;35:*.png=01;35:*.svg=01;35:*.webp=01;35:" +
+	"*.mp4=01;35:*.mkv=01;35:*.webm=01;35:*.avi=01;35:" +
+	"*.flac=00;36:*.mp3=00;36:*.ogg=00;36:*.wav=00;36"
+
+// LSColors holds the styles parsed from an LS_COLORS/dircolors specification,
+// indexed by file type indicator (di, ln, ex...) and by filename globs (*.tar).
+type LSColors struct {
+	types map[string]string
+	globs []lsGlob
+}
+
+type lsGlob struct {
+	pattern string
+	style   string
+}
+
+// ParseLSColors parses a colon-separated list of key=style entries, as
+// found in the LS_COLORS environment variable. Invalid entries are ignored.
+func ParseLSColors(spec string) *LSColors {
+	colors := &LSColors{types: make(map[string]string)}
+
+	for _, entry := range strings.Split(spec, ":") {
+		key, style, found := strings.Cut(entry, "=")
+		if !found || key == "" {
+			continue
+		}
+
+		if strings.ContainsAny(key, "*?[") {
+			colors.globs = append(colors.globs, lsGlob{pattern: key, style: style})
+		} else {
+			colors.types[key] = style
+		}
+	}
+
+	return colors
+}
+
+// LoadLSColors parses the LS_COLORS environment variable,
+// or the default dircolors database if it is not set.
+func LoadLSColors() *LSColors {
+	if spec := os.Getenv("LS_COLORS"); spec != "" {
+		return ParseLSColors(spec)
+	}
+
+	return ParseLSColors(defaultLSColors)
+}
+
+// Style returns the SGR style (without escapes) to use for a file. If info is nil,
+// the file is stat'ed (without following symlinks). Returns an empty string if no
+// style applies, or if the file cannot be found and no orphan style is set.
+func (c *LSColors) Style(path string, info fs.FileInfo) string {
+	if info == nil {
+		var err error
+		if info, err = os.Lstat(path); err != nil {
+			return c.get("mi")
+		}
+	}
+
+	mode := info.Mode()
+
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return c.styleLink(path)
+	case mode.IsDir():
+		return c.styleDir(mode)
+	case mode&fs.ModeNamedPipe != 0:
+		return c.get("pi")
+	case mode&fs.ModeSocket != 0:
+		return c.get("so")
+	case mode&fs.ModeCharDevice != 0:
+		return c.get("cd")
+	case mode&fs.ModeDevice != 0:
+		return c.get("bd")
+	}
+
+	// Regular files: permission bits take precedence over extensions.
+	switch {
+	case mode&fs.ModeSetuid != 0 && c.get("su") != "":
+		return c.get("su")
+	case mode&fs.ModeSetgid != 0 && c.get("sg") != "":
+		return c.get("sg")
+	case mode&0o111 != 0 && c.get("ex") != "":
+		return c.get("ex")
+	}
+
+	if style, found := c.matchGlob(filepath.Base(path)); found {
+		return clean(style)
+	}
+
+	return c.get("fi")
+}
+
+func (c *LSColors) styleLink(path string) string {
+	target, err := os.Stat(path)
+	if err != nil {
+		if style := c.get("or"); style != "" {
+			return style
+		}
+
+		return c.get("ln")
+	}
+
+	// Links can be styled according to their target.
+	if c.types["ln"] == "target" {
+		return c.Style(path, target)
+	}
+
+	return c.get("ln")
+}
+
+func (c *LSColors) styleDir(mode fs.FileMode) string {
+	sticky := mode&fs.ModeSticky != 0
+	otherWritable := mode&0o002 != 0
+
+	switch {
+	case sticky && otherWritable && c.get("tw") != "":
+		return c.get("tw")
+	case otherWritable && c.get("ow") != "":
+		return c.get("ow")
+	case sticky && c.get("st") != "":
+		return c.get("st")
+	default:
+		return c.get("di")
+	}
+}
+
+// matchGlob returns the style of the last glob matching the filename,
+// so that later entries override earlier ones, like dircolors does.
+// Globs are first matched with case, then without.
+func (c *LSColors) matchGlob(name string) (string, bool) {
+	for i := len(c.globs) - 1; i >= 0; i-- {
+		if matched, _ := filepath.Match(c.globs[i].pattern, name); matched {
+			return c.globs[i].style, true
+		}
+	}
+
+	lower := strings.ToLower(name)
+
+	for i := len(c.globs) - 1; i >= 0; i-- {
+		pattern := strings.ToLower(c.globs[i].pattern)
+		if matched, _ := filepath.Match(pattern, lower); matched {
+			return c.globs[i].style, true
+		}
+	}
+
+	return "", false
+}
+
+func (c *LSColors) get(key string) string {
+	return clean(c.types[key])
+}
+
+// clean returns an empty style for reset/no-op values.
+func clean(style string) string {
+	switch style {
+	case "0", "00", "target":
+		return ""
+	default:
+		return style
+	}
+}
@@ -0,0 +1,64 @@
+package color
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLSColors_Style(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]fs.FileMode{
+		"archive.tar": 0o644,
+		"IMAGE.PNG":   0o644,
+		"script.sh":   0o755,
+		"setuid":      0o755 | fs.ModeSetuid,
+		"plain":       0o644,
+	}
+
+	for name, mode := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "orphan")); err != nil {
+		t.Fatal(err)
+	}
+
+	colors := ParseLSColors("di=01;34:ln=01;36:or=40;31;01:ex=01;32:su=37;41:fi=0:*.tar=01;31:*.png=01;35:invalid")
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "Directory", file: "dir", want: "01;34"},
+		{name: "Extension", file: "archive.tar", want: "01;31"},
+		{name: "Extension (case-insensitive)", file: "IMAGE.PNG", want: "01;35"},
+		{name: "Executable", file: "script.sh", want: "01;32"},
+		{name: "Setuid over executable", file: "setuid", want: "37;41"},
+		{name: "Reset style", file: "plain", want: ""},
+		{name: "Orphan link", file: "orphan", want: "40;31;01"},
+		{name: "Missing file", file: "nonexistent", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := colors.Style(filepath.Join(dir, test.file), nil); got != test.want {
+				t.Errorf("LSColors.Style() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package completion
+
+import (
+	"fmt"
+)
+
+// approximate returns the candidates whose beginning is within maxErrors edits
+// (Damerau-Levenshtein distance, including transpositions) of the prefix.
+// The candidates keep their tag (and thus its options), but are displayed in
+// distinct "corrections" groups according to their number of errors, from best
+// to worst (see RawValues.EachTag).
+func (c RawValues) approximate(prefix string, maxErrors int, matcher caseMatcher) RawValues {
+	if prefix == "" || maxErrors <= 0 {
+		return nil
+	}
+
+	typed := []rune(prefix)
+	corrections := make([]RawValues, maxErrors+1)
+
+	for _, raw := range c {
+		errors := prefixDistance(typed, []rune(raw.Value), maxErrors, matcher)
+		if errors == 0 || errors > maxErrors {
+			continue
+		}
+
+		raw.errors = errors
+		corrections[errors] = append(corrections[errors], raw)
+	}
+
+	var approximated RawValues
+
+	for _, values := range corrections {
+		approximated = append(approximated, values...)
+	}
+
+	return approximated
+}
+
+// prefixDistance returns the smallest edit distance between the typed prefix and
+// any prefix of the candidate whose length is within maxErrors of the typed one.
+func prefixDistance(typed, candidate []rune, maxErrors int, matcher caseMatcher) int {
+	best := maxErrors + 1
+
+	for length := len(typed) - maxErrors; length <= len(typed)+maxErrors; length++ {
+		if length < 0 || length > len(candidate) {
+			continue
+		}
+
+		if dist := distance(typed, candidate[:length], matcher); dist < best {
+			best = dist
+		}
+	}
+
+	return best
+}
+
+// distance computes the optimal string alignment distance between two rune
+// slices, that is, the Damerau-Levenshtein distance where no substring is
+// edited more than once. Runes are compared according to the case matcher.
+func distance(src, dst []rune, matcher caseMatcher) int {
+	rows := make([][]int, len(src)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(dst)+1)
+		rows[i][0] = i
+	}
+
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(src); i++ {
+		for j := 1; j <= len(dst); j++ {
+			cost := 1
+			if matcher.equal(src[i-1], dst[j-1]) {
+				cost = 0
+			}
+
+			rows[i][j] = min(
+				rows[i-1][j]+1,      // Deletion
+				rows[i][j-1]+1,      // Insertion
+				rows[i-1][j-1]+cost, // Substitution
+			)
+
+			// Transposition
+			if i > 1 && j > 1 && matcher.equal(src[i-1], dst[j-2]) && matcher.equal(src[i-2], dst[j-1]) {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(src)][len(dst)]
+}
+
+// Closest returns the value closest to the word by edit distance (including
+// transpositions), if it is at most maxErrors edits away from it. Ties are
+// resolved in favor of the first value found.
+func Closest(word string, values []string, maxErrors int) (closest string, found bool) {
+	best := maxErrors + 1
+
+	for _, val := range values {
+		if dist := distance([]rune(word), []rune(val), caseMatcher{}); dist < best {
+			closest, best = val, dist
+		}
+	}
+
+	return closest, best <= maxErrors
+}
+
+func correctionsTag(errors int) string {
+	if errors == 1 {
+		return "corrections (1 error)"
+	}
+
+	return fmt.Sprintf("corrections (%d errors)", errors)
+}
@@ -0,0 +1,70 @@
+package completion
+
+import "testing"
+
+func TestRawValues_approximate(t *testing.T) {
+	values := RawValues{
+		{Value: "checkout", Tag: "commands"},
+		{Value: "cherry-pick", Tag: "commands"},
+		{Value: "commit", Tag: "commands"},
+		{Value: "clone", Tag: "commands"},
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		maxErrors int
+		want      map[string]int
+	}{
+		{name: "Disabled", prefix: "chekc", maxErrors: 0, want: map[string]int{}},
+		{name: "Transposition", prefix: "hceck", maxErrors: 1, want: map[string]int{"checkout": 1}},
+		{name: "Substitution", prefix: "cpmmit", maxErrors: 1, want: map[string]int{"commit": 1}},
+		{name: "Two errors", prefix: "clome", maxErrors: 2, want: map[string]int{"clone": 1, "commit": 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := values.approximate(test.prefix, test.maxErrors, caseMatcher{})
+			if len(got) != len(test.want) {
+				t.Fatalf("RawValues.approximate() = %v, want %v", got, test.want)
+			}
+
+			for _, val := range got {
+				if errors := test.want[val.Value]; errors != val.errors {
+					t.Errorf("RawValues.approximate() %q errors = %d, want %d", val.Value, val.errors, errors)
+				}
+
+				if val.Tag != "commands" {
+					t.Errorf("RawValues.approximate() %q tag = %q, want %q", val.Value, val.Tag, "commands")
+				}
+			}
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	commands := []string{"git", "grep", "go", "gofmt"}
+
+	tests := []struct {
+		name      string
+		word      string
+		maxErrors int
+		want      string
+		found     bool
+	}{
+		{name: "Transposition", word: "gti", maxErrors: 2, want: "git", found: true},
+		{name: "Insertion", word: "gerp", maxErrors: 2, want: "grep", found: true},
+		{name: "Deletion", word: "gofmtt", maxErrors: 1, want: "gofmt", found: true},
+		{name: "Too far", word: "ls", maxErrors: 1, found: false},
+		{name: "No errors allowed", word: "gti", maxErrors: 0, found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := Closest(test.word, commands, test.maxErrors)
+			if found != test.found || (found && got != test.want) {
+				t.Errorf("Closest() = (%q, %v), want (%q, %v)", got, found, test.want, test.found)
+			}
+		})
+	}
+}
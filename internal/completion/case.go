@@ -0,0 +1,140 @@
+package completion
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/reeflective/readline/inputrc"
+)
+
+// caseMatcher compares completion candidates against a prefix, according
+// to the case-insensitivity settings of the shell. All comparisons are made
+// on runes, so that multi-byte characters are correctly folded and counted.
+type caseMatcher struct {
+	ignoreCase bool // Letters are compared without case.
+	mapCase    bool // Hyphens and underscores are equivalent (only when ignoring case).
+}
+
+// newCaseMatcher returns a matcher for the given prefix. When completion-smart-case
+// is enabled, matching is case-insensitive only if the prefix has no uppercase letters.
+// As in GNU readline, completion-map-case only applies when case is ignored.
+func newCaseMatcher(config *inputrc.Config, prefix []rune) caseMatcher {
+	matcher := caseMatcher{
+		ignoreCase: config.GetBool("completion-ignore-case"),
+	}
+
+	if config.GetBool("completion-smart-case") {
+		matcher.ignoreCase = !hasUpper(prefix)
+	}
+
+	matcher.mapCase = matcher.ignoreCase && config.GetBool("completion-map-case")
+
+	return matcher
+}
+
+// fold returns the canonical form of a rune, against which other runes are compared.
+func (m caseMatcher) fold(r rune) rune {
+	if m.mapCase && r == '_' {
+		return '-'
+	}
+
+	if m.ignoreCase {
+		return unicode.ToLower(r)
+	}
+
+	return r
+}
+
+// equal returns true if both runes are equivalent.
+func (m caseMatcher) equal(a, b rune) bool {
+	return m.fold(a) == m.fold(b)
+}
+
+// hasPrefix returns true if val starts with an equivalent of prefix.
+func (m caseMatcher) hasPrefix(val, prefix string) bool {
+	for _, pr := range prefix {
+		vr, size := utf8.DecodeRuneInString(val)
+		if size == 0 || !m.equal(vr, pr) {
+			return false
+		}
+
+		val = val[size:]
+	}
+
+	return true
+}
+
+// commonPrefix returns the longest prefix shared by all values. Where values
+// agree on a rune (case included), this rune is used. Otherwise, the rune typed
+// by the user in the original prefix is kept, or the one of the first value.
+func (m caseMatcher) commonPrefix(typed string, vals []string) string {
+	if len(vals) == 0 {
+		return ""
+	}
+
+	first := []rune(vals[0])
+	others := make([][]rune, 0, len(vals)-1)
+
+	for _, val := range vals[1:] {
+		others = append(others, []rune(val))
+	}
+
+	typedRunes := []rune(typed)
+	common := make([]rune, 0, len(first))
+
+	for i, r := range first {
+		exact := true
+
+		for _, other := range others {
+			if i >= len(other) || !m.equal(r, other[i]) {
+				return string(common)
+			}
+
+			exact = exact && other[i] == r
+		}
+
+		if !exact && i < len(typedRunes) && m.equal(r, typedRunes[i]) {
+			r = typedRunes[i]
+		}
+
+		common = append(common, r)
+	}
+
+	return string(common)
+}
+
+// regexp returns an isearch pattern which, when case is mapped,
+// matches either hyphens or underscores for each one of them.
+// Escaped characters and bracket expressions are left untouched.
+func (m caseMatcher) regexp(pattern []rune) string {
+	if !m.mapCase {
+		return string(pattern)
+	}
+
+	var (
+		builder strings.Builder
+		escaped bool
+		bracket bool
+	)
+
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case !bracket && (r == '-' || r == '_'):
+			builder.WriteString("[-_]")
+			continue
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,54 @@
+package completion
+
+import "testing"
+
+func TestCaseMatcher_hasPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher caseMatcher
+		val     string
+		prefix  string
+		want    bool
+	}{
+		{name: "Exact case", matcher: caseMatcher{}, val: "Foo", prefix: "Fo", want: true},
+		{name: "Case mismatch", matcher: caseMatcher{}, val: "Foo", prefix: "fo", want: false},
+		{name: "Ignore case", matcher: caseMatcher{ignoreCase: true}, val: "Foo", prefix: "fO", want: true},
+		{name: "Multibyte ignore case", matcher: caseMatcher{ignoreCase: true}, val: "Éclair", prefix: "éc", want: true},
+		{name: "Map case", matcher: caseMatcher{ignoreCase: true, mapCase: true}, val: "foo_bar", prefix: "foo-b", want: true},
+		{name: "No map case", matcher: caseMatcher{ignoreCase: true}, val: "foo_bar", prefix: "foo-b", want: false},
+		{name: "Prefix longer", matcher: caseMatcher{}, val: "fo", prefix: "foo", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.matcher.hasPrefix(test.val, test.prefix); got != test.want {
+				t.Errorf("caseMatcher.hasPrefix() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCaseMatcher_commonPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher caseMatcher
+		typed   string
+		vals    []string
+		want    string
+	}{
+		{name: "Exact case", matcher: caseMatcher{}, typed: "f", vals: []string{"foobar", "foobaz"}, want: "fooba"},
+		{name: "Recase to candidates", matcher: caseMatcher{ignoreCase: true}, typed: "f", vals: []string{"FooBar", "FooBaz"}, want: "FooBa"},
+		{name: "Keep typed case when ambiguous", matcher: caseMatcher{ignoreCase: true}, typed: "fo", vals: []string{"Foo", "foO"}, want: "foo"},
+		{name: "Multibyte", matcher: caseMatcher{ignoreCase: true}, typed: "é", vals: []string{"Éété", "Éétait"}, want: "Éét"},
+		{name: "Map case", matcher: caseMatcher{ignoreCase: true, mapCase: true}, typed: "a", vals: []string{"a-b-c", "a_b_d"}, want: "a-b-"},
+		{name: "No values", matcher: caseMatcher{}, typed: "a", vals: nil, want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.matcher.commonPrefix(test.typed, test.vals); got != test.want {
+				t.Errorf("caseMatcher.commonPrefix() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ type Candidate struct {
 	// completions, comma-separated completions, etc.
 	noSpace SuffixMatcher
 
+	errors     int // Number of errors of approximate matches (corrections) of the prefix.
 	displayLen int // Real length of the displayed candidate, that is not counting escaped sequences.
 	descLen    int
 }
@@ -33,6 +34,7 @@ type Values struct {
 	ListSep  map[string]string
 	Pad      map[string]bool
 	Escapes  map[string]bool
+	Preview  func(Candidate) string
 
 	// Initially this will be set to the part of the current word
 	// from the beginning of the word up to the position of the cursor.
@@ -44,6 +46,10 @@ type Values struct {
 	// It may be altered so that inserted completions don't overwrite
 	// entirely any suffix when completing in the middle of a word.
 	SUFFIX string
+
+	// NoFilter keeps all candidates, even those not matching the prefix:
+	// they still replace it when inserted (like whole line alternatives).
+	NoFilter bool
 }
 
 // AddRaw adds completion values in bulk.
@@ -1,9 +1,7 @@
 package completion
 
 import (
-	"bufio"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/reeflective/readline/internal/color"
@@ -27,16 +25,20 @@ func Display(eng *Engine, maxRows int) {
 		return
 	}
 
-	// The final completions string to print.
+	// Reserve some space for the preview of the selected candidate, if any.
+	previewRows := eng.previewRows(maxRows)
+
+	// Only render the completion rows that fit within our terminal.
 	completions := term.ClearLineAfter
+	menu, usedY := eng.renderMenu(maxRows - previewRows)
+	completions += menu
+	eng.usedY = usedY
 
-	for _, group := range eng.groups {
-		completions += eng.renderCompletions(group)
+	if previewRows > 0 {
+		completions += eng.renderPreview(previewRows)
+		eng.usedY += previewRows
 	}
 
-	// Crop the completions so that it fits within our terminal
-	completions, eng.usedY = eng.cropCompletions(completions, maxRows)
-
 	if completions != "" {
 		fmt.Print(completions)
 	}
@@ -48,55 +50,114 @@ func Coordinates(e *Engine) int {
 	return e.usedY
 }
 
-// renderCompletions renders all completions in a given list (with aliases or not).
-// The descriptions list argument is optional.
-func (e *Engine) renderCompletions(grp *group) string {
+// renderMenu renders the completion rows visible within maxRows terminal rows,
+// scrolling the menu so that the selected candidate is always visible. Only those
+// rows are formatted, so that the cost of rendering does not depend on the number
+// of candidates. If some rows are hidden, a position indicator is added below.
+// Returns the rendered rows and the number of terminal rows used by them.
+func (e *Engine) renderMenu(maxRows int) (menu string, usedY int) {
+	total := e.menuRows()
+	if total == 0 || maxRows < 1 {
+		return "", 0
+	}
+
+	visible := total
+	if total > maxRows-1 {
+		visible = max(maxRows-1, 1)
+	}
+
+	e.scrollMenu(visible)
+
 	var builder strings.Builder
 
-	if len(grp.rows) == 0 {
-		return ""
+	var row int
+
+	for _, grp := range e.groups {
+		if len(grp.rows) == 0 {
+			continue
+		}
+
+		// Skip the groups entirely above the visible window.
+		rows := len(grp.rows)
+		if grp.tag != "" {
+			rows++
+		}
+
+		if row+rows <= e.menuOffset {
+			row += rows
+			continue
+		}
+
+		if grp.tag != "" {
+			if row >= e.menuOffset {
+				tag := fmt.Sprintf("%s%s%s %s", color.Bold, color.FgYellow, grp.tag, color.Reset)
+				builder.WriteString(tag + term.ClearLineAfter + term.NewlineReturn)
+			}
+			row++
+		}
+
+		// And only render the visible rows of this group.
+		first := max(e.menuOffset-row, 0)
+		last := min(e.menuOffset+visible-row, len(grp.rows))
+
+		for rowIndex := first; rowIndex < last; rowIndex++ {
+			builder.WriteString(e.renderRow(grp, rowIndex))
+			builder.WriteString(term.ClearLineAfter + term.NewlineReturn)
+		}
+
+		row += len(grp.rows)
+		if row >= e.menuOffset+visible {
+			break
+		}
 	}
 
-	if grp.tag != "" {
-		tag := fmt.Sprintf("%s%s%s %s", color.Bold, color.FgYellow, grp.tag, color.Reset)
-		builder.WriteString(tag + term.ClearLineAfter + term.NewlineReturn)
+	menu = strings.TrimSuffix(builder.String(), term.NewlineReturn)
+
+	if visible == total {
+		return menu, visible - 1
 	}
 
-	for rowIndex, row := range grp.rows {
-		for columnIndex := range grp.columnsWidth {
-			var value Candidate
+	menu += term.NewlineReturn + e.menuPosition(visible, total) + term.ClearLineAfter
 
-			// If there are aliases, we might have no completions at the current
-			// coordinates, so just print the corresponding padding and return.
-			if len(row) > columnIndex {
-				value = row[columnIndex]
-			}
+	return menu, visible
+}
 
-			// Apply all highlightings to the displayed value:
-			// selection, prefixes, styles and other things,
-			padding := grp.getPad(value, columnIndex, false)
-			isSelected := rowIndex == grp.posY && columnIndex == grp.posX && grp.isCurrent
-			display := e.highlightDisplay(grp, value, padding, columnIndex, isSelected)
+// renderRow renders a single row of completions in a group (with aliases or not).
+func (e *Engine) renderRow(grp *group, rowIndex int) string {
+	var builder strings.Builder
 
-			builder.WriteString(display)
+	row := grp.rows[rowIndex]
 
-			// Add description if no aliases, or if done with them.
-			onLast := columnIndex == len(grp.columnsWidth)-1
-			if grp.aliased && onLast && value.Description == "" {
-				value = row[0]
-			}
+	for columnIndex := range grp.columnsWidth {
+		var value Candidate
+
+		// If there are aliases, we might have no completions at the current
+		// coordinates, so just print the corresponding padding and return.
+		if len(row) > columnIndex {
+			value = row[columnIndex]
+		}
 
-			if !grp.aliased || onLast {
-				grp.maxDescAllowed = grp.setMaximumSizes(columnIndex)
+		// Apply all highlightings to the displayed value:
+		// selection, prefixes, styles and other things,
+		padding := grp.getPad(value, columnIndex, false)
+		isSelected := rowIndex == grp.posY && columnIndex == grp.posX && grp.isCurrent
+		display := e.highlightDisplay(grp, value, padding, columnIndex, isSelected)
 
-				descPad := grp.getPad(value, columnIndex, true)
-				desc := e.highlightDesc(grp, value, descPad, rowIndex, columnIndex, isSelected)
-				builder.WriteString(desc)
-			}
+		builder.WriteString(display)
+
+		// Add description if no aliases, or if done with them.
+		onLast := columnIndex == len(grp.columnsWidth)-1
+		if grp.aliased && onLast && value.Description == "" {
+			value = row[0]
 		}
 
-		// We're done for this line.
-		builder.WriteString(term.ClearLineAfter + term.NewlineReturn)
+		if !grp.aliased || onLast {
+			grp.maxDescAllowed = grp.setMaximumSizes(columnIndex)
+
+			descPad := grp.getPad(value, columnIndex, true)
+			desc := e.highlightDesc(grp, value, descPad, rowIndex, columnIndex, isSelected)
+			builder.WriteString(desc)
+		}
 	}
 
 	return builder.String()
@@ -121,6 +182,11 @@ func (e *Engine) highlightDisplay(grp *group, val Candidate, pad, col int, selec
 		// If the comp is currently selected, overwrite any highlighting already applied.
 		userStyle := color.UnquoteRC(e.config.GetString("completion-selection-style"))
 		selectionHighlightStyle := color.Fmt(color.Bg+"255") + userStyle
+
+		if e.isMarked(val) {
+			selectionHighlightStyle += color.UnquoteRC(e.config.GetString("completion-marked-style"))
+		}
+
 		candidate = selectionHighlightStyle + candidate
 
 		if grp.aliased {
@@ -129,12 +195,21 @@ func (e *Engine) highlightDisplay(grp *group, val Candidate, pad, col int, selec
 	} else {
 		// Highlight the prefix if any and configured for it.
 		if e.config.GetBool("colored-completion-prefix") && e.prefix != "" {
-			if prefixMatch, err := regexp.Compile("^" + e.prefix); err == nil {
-				prefixColored := color.Bold + color.FgBlue + e.prefix + color.BoldReset + color.FgDefault + style
-				candidate = prefixMatch.ReplaceAllString(candidate, prefixColored)
+			matcher := newCaseMatcher(e.config, []rune(e.prefix))
+
+			if matcher.hasPrefix(candidate, e.prefix) {
+				runes := []rune(candidate)
+				prefixLen := len([]rune(e.prefix))
+				prefixColored := color.Bold + color.FgBlue + string(runes[:prefixLen]) + color.BoldReset + color.FgDefault + style
+				candidate = prefixColored + string(runes[prefixLen:])
 			}
 		}
 
+		// Marked candidates are flagged with their own style.
+		if e.isMarked(val) {
+			style += color.UnquoteRC(e.config.GetString("completion-marked-style"))
+		}
+
 		candidate = style + candidate + color.Reset
 	}
 
@@ -170,95 +245,3 @@ func (e *Engine) highlightDesc(grp *group, val Candidate, pad, row, col int, sel
 
 	return compDescStyle + desc + color.Reset + padded
 }
-
-// cropCompletions - When the user cycles through a completion list longer
-// than the console MaxTabCompleterRows value, we crop the completions string
-// so that "global" cycling (across all groups) is printed correctly.
-func (e *Engine) cropCompletions(comps string, maxRows int) (cropped string, usedY int) {
-	// Get the current absolute candidate position
-	absPos := e.getAbsPos()
-
-	// Scan the completions for cutting them at newlines
-	scanner := bufio.NewScanner(strings.NewReader(comps))
-
-	// If absPos < MaxTabCompleterRows, cut below MaxTabCompleterRows and return
-	if absPos < maxRows-1 {
-		return e.cutCompletionsBelow(scanner, maxRows)
-	}
-
-	// If absolute > MaxTabCompleterRows, cut above and below and return
-	//      -> This includes de facto when we tabCompletionReverse
-	if absPos >= maxRows-1 {
-		return e.cutCompletionsAboveBelow(scanner, maxRows, absPos)
-	}
-
-	return
-}
-
-func (e *Engine) cutCompletionsBelow(scanner *bufio.Scanner, maxRows int) (string, int) {
-	var count int
-	var cropped string
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if count < maxRows-1 {
-			cropped += line + term.NewlineReturn
-			count++
-		} else {
-			break
-		}
-	}
-
-	cropped = strings.TrimSuffix(cropped, term.NewlineReturn)
-
-	// Add hint for remaining completions, if any.
-	_, used := e.completionCount()
-	remain := used - count
-
-	if remain <= 0 {
-		return cropped, count - 1
-	}
-
-	cropped += fmt.Sprintf(term.NewlineReturn+color.Dim+color.FgYellow+" %d more completion rows... (scroll down to show)"+color.Reset, remain)
-
-	return cropped, count
-}
-
-func (e *Engine) cutCompletionsAboveBelow(scanner *bufio.Scanner, maxRows, absPos int) (string, int) {
-	cutAbove := absPos - maxRows + 1
-
-	var cropped string
-	var count int
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if count <= cutAbove {
-			count++
-
-			continue
-		}
-
-		if count > cutAbove && count <= absPos {
-			cropped += line + term.NewlineReturn
-			count++
-		} else {
-			break
-		}
-	}
-
-	cropped = strings.TrimSuffix(cropped, term.NewlineReturn)
-	count -= cutAbove + 1
-
-	// Add hint for remaining completions, if any.
-	_, used := e.completionCount()
-	remain := used - (maxRows + cutAbove)
-
-	if remain <= 0 {
-		return cropped, count - 1
-	}
-
-	cropped += fmt.Sprintf(term.NewlineReturn+color.Dim+color.FgYellow+" %d more completion rows... (scroll down to show)"+color.Reset, remain)
-
-	return cropped, count
-}
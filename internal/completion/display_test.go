@@ -0,0 +1,91 @@
+package completion
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/core"
+	"github.com/reeflective/readline/internal/ui"
+)
+
+// newBenchEngine returns a completion engine with a given number of generated
+// candidates, with the selector placed on the candidate in the middle of them.
+func newBenchEngine(b *testing.B, count int, described bool) *Engine {
+	b.Helper()
+
+	line := core.Line("")
+	cursor := core.NewCursor(&line)
+
+	eng := NewEngine(new(ui.Hint), nil, inputrc.NewDefaultConfig())
+	Init(eng, nil, &line, cursor, nil, nil)
+
+	values := make(RawValues, count)
+	for i := range values {
+		values[i] = Candidate{Value: fmt.Sprintf("object-%06d", i), Tag: "objects"}
+		if described {
+			values[i].Description = fmt.Sprintf("object number %d", i)
+		}
+	}
+
+	eng.prepare(AddRaw(values))
+
+	grp := eng.groups[0]
+	grp.posY = len(grp.rows) / 2
+	grp.posX, grp.isCurrent = 0, true
+	eng.selected = grp.selected()
+
+	return eng
+}
+
+func benchmarkRenderMenu(b *testing.B, count int, described bool) {
+	eng := newBenchEngine(b, count, described)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		eng.renderMenu(40)
+	}
+}
+
+func BenchmarkEngine_renderMenu10k(b *testing.B)           { benchmarkRenderMenu(b, 10_000, false) }
+func BenchmarkEngine_renderMenu100k(b *testing.B)          { benchmarkRenderMenu(b, 100_000, false) }
+func BenchmarkEngine_renderMenuDescribed10k(b *testing.B)  { benchmarkRenderMenu(b, 10_000, true) }
+func BenchmarkEngine_renderMenuDescribed100k(b *testing.B) { benchmarkRenderMenu(b, 100_000, true) }
+
+func TestEngine_renderMenu(t *testing.T) {
+	line := core.Line("")
+	cursor := core.NewCursor(&line)
+
+	eng := NewEngine(new(ui.Hint), nil, inputrc.NewDefaultConfig())
+	Init(eng, nil, &line, cursor, nil, nil)
+
+	values := make(RawValues, 100)
+	for i := range values {
+		values[i] = Candidate{Value: fmt.Sprintf("value-%03d", i), Description: fmt.Sprintf("desc %d", i), Tag: "values"}
+	}
+
+	comps := AddRaw(values)
+	comps.ListLong["*"] = true
+
+	eng.prepare(comps)
+
+	grp := eng.groups[0]
+	grp.posX, grp.posY, grp.isCurrent = 0, 50, true
+	eng.selected = grp.selected()
+
+	// 9 visible rows (tag excluded) and the position indicator.
+	_, usedY := eng.renderMenu(10)
+	if usedY != 9 {
+		t.Errorf("Engine.renderMenu() usedY = %d, want %d", usedY, 9)
+	}
+
+	// The selected row (tag + 50) must be the last visible one.
+	if want := 51 - 9 + 1; eng.menuOffset != want {
+		t.Errorf("Engine.menuOffset = %d, want %d", eng.menuOffset, want)
+	}
+
+	if got := eng.selectedIndex(); got != 51 {
+		t.Errorf("Engine.selectedIndex() = %d, want %d", got, 51)
+	}
+}
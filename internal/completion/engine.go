@@ -31,12 +31,24 @@ type Engine struct {
 	sm          SuffixMatcher // The suffix matcher is kept for removal after actually inserting the candidate.
 	selected    Candidate     // The currently selected item, not yet a real part of the input line.
 	prefix      string        // The current tab completion prefix against which to build candidates
+	rawPrefix   string        // The prefix as found in the line, with its quotes and escapes.
+	quote       rune          // The quoting of the prefix: an opening quote, a backslash (escapes) or 0.
 	suffix      string        // The current word suffix
 	inserted    []rune        // The selected candidate (inserted in line) without prefix or suffix.
+	corrected   bool          // The candidates are corrections of the prefix (approximate matching).
 	usedY       int           // Comprehensive size offset (terminal rows) of the currently built completions.
 	auto        bool          // Is the engine autocompleting ?
 	autoForce   bool          // Special autocompletion mode (isearch-style)
 	skipDisplay bool          // Don't display completions if there are some.
+	marked      []marked      // Candidates marked for bulk insertion, in marking order.
+	menuOffset  int           // The first completion row displayed (scrolling).
+
+	// Preview pane
+	preview       func(Candidate) string // Generates a preview for the selected candidate, if any.
+	previewValue  string                 // The candidate for which the preview was generated.
+	previewLines  []string               // The lines of the current preview.
+	previewOffset int                    // The first preview line displayed (scrolling).
+	previewHeight int                    // The number of preview lines displayed in the pane.
 
 	// Incremental search
 	IsearchRegex       *regexp.Regexp // Holds the current search regex match
@@ -50,6 +62,7 @@ type Engine struct {
 	isearchStartBuf    string         // The buffer before starting isearch
 	isearchStartCursor int            // The cursor position before starting isearch
 	isearchLast        string         // The last non-incremental buffer.
+	isearchEx          bool           // The minibuffer is the Vim ex command line.
 	isearchModeExit    keymap.Mode    // The main keymap to restore after exiting isearch
 }
 
@@ -258,6 +271,13 @@ func (e *Engine) ClearMenu(completions bool) {
 
 	e.resetValues(completions, false)
 
+	// The preview is cached by candidate value only, but
+	// the same value might be generated by other completers.
+	e.previewValue = ""
+	e.previewLines = nil
+	e.previewOffset = 0
+	e.previewHeight = 0
+
 	if e.keymap.Local() == keymap.MenuSelect {
 		e.keymap.SetLocal("")
 	}
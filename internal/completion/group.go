@@ -15,11 +15,13 @@ import (
 // display types, autosuffix removal matchers, under their tag heading.
 type group struct {
 	tag               string        // Printed on top of the group's completions
+	valuesTag         string        // Tag of the group's completions, for tag-specific options.
 	rows              [][]Candidate // Values are grouped by aliases/rows, with computed paddings.
 	noSpace           SuffixMatcher // Suffixes to remove if a space or non-nil character is entered after the completion.
 	columnsWidth      []int         // Computed width for each column of completions, when aliases
 	descriptionsWidth []int         // Computed width for each column of completions, when aliases
 	listSeparator     string        // This is used to separate completion candidates from their descriptions.
+	valueSeparator    string        // Used to separate several marked candidates when inserted together.
 	list              bool          // Force completions to be listed instead of grided
 	noSort            bool          // Don't sort completions
 	aliased           bool          // Are their aliased completions
@@ -38,9 +40,11 @@ type group struct {
 }
 
 // newCompletionGroup initializes a group of completions to be displayed in the same area/header.
-func (e *Engine) newCompletionGroup(comps Values, tag string, vals RawValues, descriptions []string) {
+// The heading is displayed on top of the group, and the tag is used for tag-specific options.
+func (e *Engine) newCompletionGroup(comps Values, tag, heading string, vals RawValues, descriptions []string) {
 	grp := &group{
-		tag:          tag,
+		tag:          heading,
+		valuesTag:    tag,
 		noSpace:      comps.NoSpace,
 		posX:         -1,
 		posY:         -1,
@@ -90,24 +94,28 @@ func (g *group) initOptions(eng *Engine, comps *Values, tag string, vals RawValu
 	}
 
 	// Strip escaped characters in the value component.
-	g.preserveEscapes = comps.Escapes[g.tag]
+	g.preserveEscapes = comps.Escapes[tag]
 	if !g.preserveEscapes {
 		g.preserveEscapes = comps.Escapes["*"]
 	}
 
 	// Always list long commands when they have descriptions.
-	if strings.HasSuffix(g.tag, "commands") && len(vals) > 0 && vals[0].Description != "" {
+	if strings.HasSuffix(tag, "commands") && len(vals) > 0 && vals[0].Description != "" {
 		g.list = true
 	}
 
 	// Description list separator
+	g.valueSeparator = " "
+
 	listSep, found := comps.ListSep[tag]
 	if !found {
 		if allSep, found := comps.ListSep["*"]; found {
 			g.listSeparator = allSep
+			g.valueSeparator = allSep
 		}
 	} else {
 		g.listSeparator = listSep
+		g.valueSeparator = listSep
 	}
 
 	// Override sorting or sort if needed
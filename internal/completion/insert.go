@@ -1,9 +1,12 @@
 package completion
 
 import (
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
 	"github.com/reeflective/readline/internal/core"
 	"github.com/reeflective/readline/internal/keymap"
 )
@@ -115,14 +118,18 @@ func (e *Engine) acceptCandidate() {
 	completion := e.prepareSuffix()
 	e.inserted = []rune(completion)
 
-	// Remove the line prefix and insert the candidate.
-	e.cursor.Move(-1 * len(e.prefix))
-	e.line.Cut(e.cursor.Pos(), e.cursor.Pos()+len(e.prefix))
+	// Remove the line prefix and insert the candidate:
+	// the candidate replaces the prefix as a whole, so
+	// that the line is re-cased to match the candidate.
+	prefixLen := utf8.RuneCountInString(e.rawPrefix)
+	e.cursor.Move(-1 * prefixLen)
+	e.line.Cut(e.cursor.Pos(), e.cursor.Pos()+prefixLen)
 	e.cursor.InsertAt(e.inserted...)
 
 	// And forget about this inserted completion.
 	e.inserted = make([]rune, 0)
 	e.prefix = ""
+	e.rawPrefix = ""
 	e.suffix = ""
 }
 
@@ -135,7 +142,8 @@ func (e *Engine) insertCandidate() {
 
 	e.selected = grp.selected()
 
-	if len(e.selected.Value) < len(e.prefix) {
+	// Corrections replace the prefix, and can thus be shorter than it.
+	if !e.corrected && utf8.RuneCountInString(e.selected.Value) < utf8.RuneCountInString(e.prefix) {
 		return
 	}
 
@@ -152,11 +160,49 @@ func (e *Engine) insertCandidate() {
 	e.compCursor.Set(e.cursor.Pos())
 
 	// Remove the line prefix and insert the candidate.
-	e.compCursor.Move(-1 * len(e.prefix))
-	e.compLine.Cut(e.compCursor.Pos(), e.compCursor.Pos()+len(e.prefix))
+	prefixLen := utf8.RuneCountInString(e.rawPrefix)
+	e.compCursor.Move(-1 * prefixLen)
+	e.compLine.Cut(e.compCursor.Pos(), e.compCursor.Pos()+prefixLen)
 	e.compCursor.InsertAt(e.inserted...)
 }
 
+// InsertCommonPrefix inserts the longest prefix shared by all candidates
+// in place of the current completion prefix, if it is longer than it.
+// Returns true if the line was modified.
+func (e *Engine) InsertCommonPrefix() bool {
+	var values []string
+
+	for _, grp := range e.groups {
+		for _, row := range grp.rows {
+			for _, val := range row {
+				if val.Value != "" {
+					values = append(values, color.Strip(val.Value))
+				}
+			}
+		}
+	}
+
+	matcher := newCaseMatcher(e.config, []rune(e.prefix))
+	common := matcher.commonPrefix(e.prefix, values)
+
+	if utf8.RuneCountInString(common) <= utf8.RuneCountInString(e.prefix) {
+		return false
+	}
+
+	// The common prefix is quoted like the prefix, but any
+	// opening quote is left open, since the word is partial.
+	quoted := e.quoted(common, false)
+
+	prefixLen := utf8.RuneCountInString(e.rawPrefix)
+	e.cursor.Move(-1 * prefixLen)
+	e.line.Cut(e.cursor.Pos(), e.cursor.Pos()+prefixLen)
+	e.cursor.InsertAt([]rune(quoted)...)
+	e.prefix = common
+	e.rawPrefix = quoted
+
+	return true
+}
+
 // prepareSuffix caches any suffix matcher associated with the completion candidate
 // to be inserted/accepted into the input line, and trims it if required at this point.
 func (e *Engine) prepareSuffix() (comp string) {
@@ -165,20 +211,28 @@ func (e *Engine) prepareSuffix() (comp string) {
 		return
 	}
 
-	comp = e.selected.Value
-	prefix := len(e.prefix)
+	// The candidate is quoted like the prefix it replaces.
+	comp = e.quoted(e.selected.Value, true)
+	compLen := utf8.RuneCountInString(comp)
+	prefix := utf8.RuneCountInString(e.rawPrefix)
 
 	// When the completion has a size of 1, don't remove anything:
 	// stacked flags, for example, will never be inserted otherwise.
-	if len(comp) > 0 && len(comp[prefix:]) <= 1 {
+	if compLen > 0 && compLen-prefix <= 1 {
 		return
 	}
 
+	// A closing quote ends the word: there is no suffix to remove.
+	if (e.quote == '\'' || e.quote == '"') && strings.HasSuffix(comp, string(e.quote)) {
+		e.sm = SuffixMatcher{}
+		return comp
+	}
+
 	// If we are to even consider removing a suffix, we keep the suffix
 	// matcher for later: whatever the decision we take here will be identical
 	// to the one we take while removing suffix in "non-virtual comp" mode.
 	e.sm = cur.noSpace
-	e.sm.pos = e.cursor.Pos() + len(comp) - prefix - 1
+	e.sm.pos = e.cursor.Pos() + compLen - prefix - 1
 
 	return comp
 }
@@ -0,0 +1,92 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/core"
+	"github.com/reeflective/readline/internal/ui"
+)
+
+func TestEngine_insertCandidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		maxErrors int
+		values    []string
+		want      string
+	}{
+		{name: "Completion", line: "gi", values: []string{"git"}, want: "git"},
+		{name: "Longer correction", line: "gti", maxErrors: 1, values: []string{"git"}, want: "git"},
+		{name: "Shorter correction", line: "gitt", maxErrors: 1, values: []string{"git", "grep"}, want: "git"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := core.Line(test.line)
+			cursor := core.NewCursor(&line)
+			cursor.Set(line.Len())
+
+			config := inputrc.NewDefaultConfig()
+			config.Set("completion-approximate-errors", test.maxErrors)
+
+			eng := NewEngine(new(ui.Hint), nil, config)
+			Init(eng, nil, &line, cursor, nil, nil)
+
+			values := make(RawValues, len(test.values))
+			for i, value := range test.values {
+				values[i] = Candidate{Value: value, Tag: "values"}
+			}
+
+			eng.prepare(AddRaw(values))
+
+			if len(eng.groups) == 0 {
+				t.Fatalf("Engine.prepare() generated no groups")
+			}
+
+			eng.groups[0].isCurrent = true
+			eng.insertCandidate()
+
+			if eng.compLine == nil || string(*eng.compLine) != test.want {
+				t.Errorf("Engine.insertCandidate() line = %v, want %q", eng.compLine, test.want)
+			}
+		})
+	}
+}
+
+func TestEngine_prepare_corrections(t *testing.T) {
+	line := core.Line("gti")
+	cursor := core.NewCursor(&line)
+	cursor.Set(line.Len())
+
+	config := inputrc.NewDefaultConfig()
+	config.Set("completion-approximate-errors", 1)
+
+	eng := NewEngine(new(ui.Hint), nil, config)
+	Init(eng, nil, &line, cursor, nil, nil)
+
+	comps := AddRaw(RawValues{{Value: "git", Tag: "commands"}})
+	comps.ListSep = map[string]string{"commands": ","}
+	comps.Escapes = map[string]bool{"commands": true}
+
+	eng.prepare(comps)
+
+	if len(eng.groups) != 1 {
+		t.Fatalf("Engine.prepare() generated %d groups, want 1", len(eng.groups))
+	}
+
+	// Corrections are displayed apart, but keep the options of their tag.
+	grp := eng.groups[0]
+
+	if grp.tag != "corrections (1 error)" {
+		t.Errorf("group heading = %q, want %q", grp.tag, "corrections (1 error)")
+	}
+
+	if grp.valueSeparator != "," || !grp.preserveEscapes {
+		t.Errorf("group options = %q, %v, want %q, %v", grp.valueSeparator, grp.preserveEscapes, ",", true)
+	}
+
+	if got := grp.rows[0][0].Tag; got != "commands" {
+		t.Errorf("candidate tag = %q, want %q", got, "commands")
+	}
+}
@@ -129,7 +129,11 @@ func (e *Engine) NonIsearchStart(name string, repeat, forward, substring bool) {
 
 // NonIsearchStop exits the non-incremental search mode.
 func (e *Engine) NonIsearchStop() {
-	e.isearchLast = string(*e.isearchBuf)
+	if !e.isearchEx {
+		e.isearchLast = string(*e.isearchBuf)
+	}
+
+	e.isearchEx = false
 	e.isearchBuf = nil
 	e.IsearchRegex = nil
 	e.isearchCur = nil
@@ -142,6 +146,12 @@ func (e *Engine) NonIsearchStop() {
 	e.hint.Reset()
 }
 
+// NonIsearchSetLast sets the buffer of the last non-incremental search,
+// used by the next search started with repeat (eg. vi-search-again).
+func (e *Engine) NonIsearchSetLast(search string) {
+	e.isearchLast = search
+}
+
 // NonIncrementallySearching returns true if the completion engine
 // is currently using a minibuffer for non-incremental search mode.
 func (e *Engine) NonIncrementallySearching() (searching, forward, substring bool) {
@@ -152,12 +162,33 @@ func (e *Engine) NonIncrementallySearching() (searching, forward, substring bool
 	return
 }
 
+// ExStart starts the Vim ex command line, with an initial command line: it is
+// a non-incremental search minibuffer, run as a command line when accepted.
+func (e *Engine) ExStart(cmdline string) {
+	e.NonIsearchStart(":", false, false, false)
+	e.isearchBuf.Set([]rune(cmdline)...)
+	e.isearchCur.Set(e.isearchBuf.Len())
+	e.isearchEx = true
+
+	e.keymap.ExCommandLineStart()
+}
+
+// IsExCommandLine returns true if the minibuffer is the Vim ex command line.
+func (e *Engine) IsExCommandLine() bool {
+	searching, _, _ := e.NonIncrementallySearching()
+	return searching && e.isearchEx
+}
+
 func (e *Engine) updateIncrementalSearch() {
-	var regexStr string
-	if hasUpper(*e.isearchBuf) {
-		regexStr = string(*e.isearchBuf)
-	} else {
-		regexStr = "(?i)" + string(*e.isearchBuf)
+	// Incremental search is always smart-case, unless case is ignored.
+	matcher := caseMatcher{
+		ignoreCase: e.config.GetBool("completion-ignore-case") || !hasUpper(*e.isearchBuf),
+	}
+	matcher.mapCase = matcher.ignoreCase && e.config.GetBool("completion-map-case")
+
+	regexStr := matcher.regexp(*e.isearchBuf)
+	if matcher.ignoreCase {
+		regexStr = "(?i)" + regexStr
 	}
 
 	var err error
@@ -190,7 +221,7 @@ func (e *Engine) updateIncrementalSearch() {
 	if e.isearchInsert && e.Matches() > 0 && e.isearchBuf.Len() > 0 {
 		// History incremental searches must replace the whole line.
 		if e.isearchReplaceLine {
-			e.prefix = ""
+			e.prefix, e.rawPrefix = "", ""
 			e.line.Set()
 			e.cursor.Set(0)
 		}
@@ -204,6 +235,11 @@ func (e *Engine) updateIncrementalSearch() {
 }
 
 func (e *Engine) updateNonIncrementalSearch() {
+	if e.isearchEx {
+		e.hint.Set(color.Bold + e.isearchName + color.Reset + string(*e.isearchBuf) + "_")
+		return
+	}
+
 	isearchHint := color.Bold + color.FgCyan + e.isearchName +
 		" (non-inc-search): " + color.Reset + color.Bold + string(*e.isearchBuf) + color.Reset + "_"
 	e.hint.Set(isearchHint)
@@ -0,0 +1,83 @@
+package completion
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/reeflective/readline/internal/color"
+)
+
+// marked is a candidate marked for bulk insertion,
+// along with the separator to use when inserting it.
+type marked struct {
+	Candidate
+	sep string
+}
+
+// ToggleMark marks the currently selected candidate for bulk insertion,
+// or unmarks it if it was already marked. Returns false if no candidate
+// is currently selected.
+func (e *Engine) ToggleMark() bool {
+	grp := e.currentGroup()
+	if grp == nil || len(grp.rows) == 0 || len(e.selected.Value) == 0 {
+		return false
+	}
+
+	comp := grp.selected()
+
+	for i, mark := range e.marked {
+		if mark.Value == comp.Value && mark.Tag == comp.Tag {
+			e.marked = append(e.marked[:i], e.marked[i+1:]...)
+			return true
+		}
+	}
+
+	e.marked = append(e.marked, marked{Candidate: comp, sep: grp.valueSeparator})
+
+	return true
+}
+
+// AcceptMarked inserts all marked candidates in the line, in place of the current
+// completion prefix, separated by their group's separator. If no candidates are
+// marked, the currently selected one is inserted. The completion menu is exited.
+func (e *Engine) AcceptMarked() {
+	if len(e.marked) == 0 {
+		e.Reset()
+		return
+	}
+
+	// Drop any virtually inserted candidate: the
+	// marked values are inserted as a single edit.
+	if len(e.selected.Value) > 0 {
+		e.cancelCompletedLine()
+	}
+
+	var values strings.Builder
+
+	for i, mark := range e.marked {
+		if i > 0 {
+			values.WriteString(e.marked[i-1].sep)
+		}
+
+		values.WriteString(e.quoted(mark.Value, true))
+	}
+
+	prefixLen := utf8.RuneCountInString(e.rawPrefix)
+	e.cursor.Move(-1 * prefixLen)
+	e.line.Cut(e.cursor.Pos(), e.cursor.Pos()+prefixLen)
+	e.cursor.InsertAt([]rune(values.String())...)
+
+	e.prefix, e.rawPrefix = "", ""
+	e.Reset()
+}
+
+// isMarked returns true if the candidate is marked for bulk insertion.
+func (e *Engine) isMarked(comp Candidate) bool {
+	for _, mark := range e.marked {
+		if mark.Value == color.Strip(comp.Value) && mark.Tag == comp.Tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package completion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/term"
+)
+
+// minPreviewRows is the minimum number of rows (including
+// the header) needed for the preview pane to be displayed.
+const minPreviewRows = 2
+
+// ScrollPreview scrolls the preview pane of the selected candidate by half
+// its displayed height, either downward (down=true) or upward.
+func (e *Engine) ScrollPreview(down bool) {
+	if e.preview == nil || len(e.previewLines) == 0 {
+		return
+	}
+
+	step := e.previewHeight / 2
+	if step < 1 {
+		step = 1
+	}
+
+	if down {
+		e.previewOffset += step
+	} else {
+		e.previewOffset -= step
+	}
+
+	if e.previewOffset > len(e.previewLines)-max(e.previewHeight, 1) {
+		e.previewOffset = len(e.previewLines) - max(e.previewHeight, 1)
+	}
+
+	if e.previewOffset < 0 {
+		e.previewOffset = 0
+	}
+}
+
+// previewRows returns the number of rows to reserve for the preview pane,
+// out of maxRows available: at most half of them, and none if too few.
+func (e *Engine) previewRows(maxRows int) int {
+	if e.preview == nil || len(e.selected.Value) == 0 {
+		return 0
+	}
+
+	// Only regenerate the preview when the selection changes.
+	if e.selected.Value != e.previewValue {
+		e.previewValue = e.selected.Value
+		e.previewOffset = 0
+
+		preview := strings.TrimSuffix(e.preview(e.selected), "\n")
+		e.previewLines = nil
+
+		if preview != "" {
+			e.previewLines = strings.Split(preview, "\n")
+		}
+	}
+
+	if len(e.previewLines) == 0 {
+		return 0
+	}
+
+	rows := len(e.previewLines) + 1
+	if rows > maxRows/2 {
+		rows = maxRows / 2
+	}
+
+	if rows < minPreviewRows {
+		return 0
+	}
+
+	return rows
+}
+
+// renderPreview returns the preview pane (a header and the visible lines),
+// each line starting with a newline, so that the number of rows used by it
+// is the number of newlines, like for the completions.
+func (e *Engine) renderPreview(rows int) string {
+	visible := rows - 1
+	width := term.GetWidth()
+
+	e.previewHeight = visible
+
+	// Keep the offset so that the pane is always full.
+	if e.previewOffset > len(e.previewLines)-visible {
+		e.previewOffset = len(e.previewLines) - visible
+	}
+
+	if e.previewOffset < 0 {
+		e.previewOffset = 0
+	}
+
+	end := e.previewOffset + visible
+	if end > len(e.previewLines) {
+		end = len(e.previewLines)
+	}
+
+	var builder strings.Builder
+
+	header := fmt.Sprintf(" preview (%d-%d/%d) ", e.previewOffset+1, end, len(e.previewLines))
+	if width > len(header)+3 {
+		header = "──" + header + strings.Repeat("─", width-len(header)-3)
+	}
+
+	builder.WriteString(term.NewlineReturn + color.Dim + header + color.Reset + term.ClearLineAfter)
+
+	for _, line := range e.previewLines[e.previewOffset:end] {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		builder.WriteString(term.NewlineReturn + color.Trim(line, width-1) + color.Reset + term.ClearLineAfter)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,44 @@
+package completion
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEngine_ScrollPreview(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+
+	tests := []struct {
+		name   string
+		height int
+		offset int
+		down   bool
+		want   int
+	}{
+		{name: "Down by half the pane", height: 10, down: true, want: 5},
+		{name: "Up by half the pane", height: 10, offset: 50, want: 45},
+		{name: "Up stops at the top", height: 10, offset: 2, want: 0},
+		{name: "Down stops at the last pane", height: 10, offset: 188, down: true, want: 190},
+		{name: "Not rendered yet", offset: 10, down: true, want: 11},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eng := &Engine{
+				preview:       func(Candidate) string { return "" },
+				previewLines:  lines,
+				previewHeight: test.height,
+				previewOffset: test.offset,
+			}
+
+			eng.ScrollPreview(test.down)
+
+			if eng.previewOffset != test.want {
+				t.Errorf("Engine.ScrollPreview() offset = %d, want %d", eng.previewOffset, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// escapedChars are escaped with a backslash when inserting candidates in unquoted words.
+const escapedChars = " \t\n'\"\\$`&;|<>()*?[]#!{}"
+
+// WordPrefix returns the unquoted and unescaped part of the shell word before
+// the cursor, which is the prefix against which completion candidates are matched.
+func WordPrefix(line []rune, cpos int) string {
+	prefix, _, _ := wordPrefix(line, cpos)
+	return prefix
+}
+
+// wordPrefix returns the part of the shell word before the cursor, both unquoted
+// (for matching candidates) and as found in the line (for replacing it), and the
+// quoting to use for candidates: the quote of the last quoted part of the prefix,
+// a backslash if it has some escaped characters, or 0 if it has neither.
+func wordPrefix(line []rune, cpos int) (prefix, raw string, quote rune) {
+	tokens := strutil.Lex(string(line))
+
+	first, _, found := strutil.WordAt(tokens, cpos)
+	if !found {
+		return "", "", 0
+	}
+
+	raw = string(line[tokens[first].Runes.Start:cpos])
+	if raw == "" {
+		return "", "", 0
+	}
+
+	// Lex the prefix alone: any quote opened before
+	// the cursor and not closed yet is then incomplete.
+	tokens = strutil.Lex(raw)
+
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case strutil.TokenWord, strutil.TokenQuoted:
+			prefix += tok.Value
+		default:
+			prefix += tok.Text
+		}
+	}
+
+	// Candidates are quoted like the end of the prefix, which
+	// might be a quoted string, closed or not, or escaped text.
+	last := tokens[len(tokens)-1]
+
+	switch {
+	case last.Kind == strutil.TokenQuoted:
+		quote = last.Quote
+	case prefix != raw:
+		quote = '\\'
+	}
+
+	return prefix, raw, quote
+}
+
+// wordSuffix returns the part of the shell word after the cursor, as found in the line.
+func wordSuffix(line []rune, cpos int) string {
+	tokens := strutil.Lex(string(line))
+
+	_, last, found := strutil.WordAt(tokens, cpos)
+	if !found {
+		return ""
+	}
+
+	return string(line[cpos:tokens[last].Runes.End])
+}
+
+// quoted returns a candidate value as it must be inserted in place of the raw prefix,
+// quoted or escaped like this prefix is. If closing is true, an opening quote is closed,
+// except for values ending with a slash (directories), on which completion can go on.
+func (e *Engine) quoted(value string, closing bool) string {
+	switch e.quote {
+	case '\'':
+		value = "'" + strings.ReplaceAll(value, "'", `'\''`)
+	case '"':
+		var builder strings.Builder
+
+		builder.WriteRune('"')
+
+		for _, char := range value {
+			if strings.ContainsRune("\"\\$`", char) {
+				builder.WriteRune('\\')
+			}
+
+			builder.WriteRune(char)
+		}
+
+		value = builder.String()
+	case '\\':
+		var builder strings.Builder
+
+		for _, char := range value {
+			if strings.ContainsRune(escapedChars, char) {
+				builder.WriteRune('\\')
+			}
+
+			builder.WriteRune(char)
+		}
+
+		return builder.String()
+	default:
+		return value
+	}
+
+	if closing && !strings.HasSuffix(value, "/") {
+		value += string(e.quote)
+	}
+
+	return value
+}
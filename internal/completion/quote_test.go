@@ -0,0 +1,62 @@
+package completion
+
+import "testing"
+
+func TestWordPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		cpos   int
+		prefix string
+		raw    string
+		quote  rune
+	}{
+		{name: "Plain word", line: "ls fo", cpos: 5, prefix: "fo", raw: "fo"},
+		{name: "After blank", line: "ls ", cpos: 3},
+		{name: "Word start", line: "ls foo", cpos: 3},
+		{name: "Inside word", line: "ls foobar", cpos: 6, prefix: "foo", raw: "foo"},
+		{name: "Open double quote", line: `cat "my fi`, cpos: 10, prefix: "my fi", raw: `"my fi`, quote: '"'},
+		{name: "Open single quote", line: `cat 'my fi`, cpos: 10, prefix: "my fi", raw: `'my fi`, quote: '\''},
+		{name: "Joined quote", line: `cat dir/"my fi`, cpos: 14, prefix: "dir/my fi", raw: `dir/"my fi`, quote: '"'},
+		{name: "Closed quote", line: `cat "my file"`, cpos: 13, prefix: "my file", raw: `"my file"`, quote: '"'},
+		{name: "Escaped blank", line: `cat my\ fi`, cpos: 10, prefix: "my fi", raw: `my\ fi`, quote: '\\'},
+		{name: "After operator", line: "ls|gr", cpos: 5, prefix: "gr", raw: "gr"},
+		{name: "Variable", line: "ls $HOME/do", cpos: 11, prefix: "$HOME/do", raw: "$HOME/do"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefix, raw, quote := wordPrefix([]rune(test.line), test.cpos)
+			if prefix != test.prefix || raw != test.raw || quote != test.quote {
+				t.Errorf("wordPrefix() = (%q, %q, %q), want (%q, %q, %q)",
+					prefix, raw, quote, test.prefix, test.raw, test.quote)
+			}
+		})
+	}
+}
+
+func TestEngine_quoted(t *testing.T) {
+	tests := []struct {
+		name    string
+		quote   rune
+		value   string
+		closing bool
+		want    string
+	}{
+		{name: "Unquoted", value: "my file", closing: true, want: "my file"},
+		{name: "Escaped", quote: '\\', value: "my file (1)", closing: true, want: `my\ file\ \(1\)`},
+		{name: "Double quoted", quote: '"', value: `a "$b"`, closing: true, want: `"a \"\$b\""`},
+		{name: "Single quoted", quote: '\'', value: "it's", closing: true, want: `'it'\''s'`},
+		{name: "Open directory", quote: '"', value: "my dir/", closing: true, want: `"my dir/`},
+		{name: "Not closing", quote: '\'', value: "my fi", want: "'my fi"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eng := &Engine{quote: test.quote}
+			if got := eng.quoted(test.value, test.closing); got != test.want {
+				t.Errorf("Engine.quoted() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
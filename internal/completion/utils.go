@@ -1,6 +1,8 @@
 package completion
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -25,7 +27,10 @@ var sanitizer = strings.NewReplacer(
 // insertion/abortion on the line.
 func (e *Engine) prepare(completions Values) {
 	e.prefix = ""
+	e.rawPrefix = ""
+	e.quote = 0
 	e.groups = make([]*group, 0)
+	e.preview = completions.Preview
 
 	e.setPrefix(completions)
 	e.setSuffix(completions)
@@ -39,6 +44,8 @@ func (e *Engine) generate(completions Values) {
 		e.hintCompletions(completions)
 	}()
 
+	e.corrected = false
+
 	// Nothing else to do if no completions
 	if len(completions.values) == 0 {
 		return
@@ -46,8 +53,22 @@ func (e *Engine) generate(completions Values) {
 
 	// Apply the prefix to the completions, and filter out any
 	// completions that don't match, optionally ignoring case.
-	matchCase := e.config.GetBool("completion-ignore-case")
-	completions.values = completions.values.FilterPrefix(e.prefix, !matchCase)
+	matcher := newCaseMatcher(e.config, []rune(e.prefix))
+	matches := completions.values
+
+	if !completions.NoFilter {
+		matches = matches.filterPrefix(e.prefix, matcher)
+	}
+
+	// If nothing matches, optionally retry with approximate matching:
+	// the corrections will replace the typed prefix when inserted.
+	if len(matches) == 0 {
+		maxErrors := e.config.GetInt("completion-approximate-errors")
+		matches = completions.values.approximate(e.prefix, maxErrors, matcher)
+		e.corrected = len(matches) > 0
+	}
+
+	completions.values = matches
 
 	// Classify, group together and initialize completions.
 	completions.values.EachTag(e.generateGroup(completions))
@@ -57,56 +78,22 @@ func (e *Engine) generate(completions Values) {
 func (e *Engine) setPrefix(completions Values) {
 	switch completions.PREFIX {
 	case "":
-		// Select the character just before the cursor.
-		cpos := e.cursor.Pos() - 1
-		if cpos < 0 {
-			cpos = 0
-		}
-
-		bpos, _ := e.line.SelectBlankWord(cpos)
-
-		// Safety checks and adjustments.
-		if bpos > cpos {
-			bpos, cpos = cpos, bpos
-		}
-
-		if cpos < e.line.Len() {
-			cpos++
-		}
-
-		// You might wonder why we trim spaces here:
-		// in practice we don't really ever want to
-		// consider "how many spaces are somewhere".
-		e.prefix = strings.TrimSpace(string((*e.line)[bpos:cpos]))
+		// The prefix is the part of the shell word before the cursor,
+		// which may start with or include quoted or escaped parts:
+		// candidates are matched against its unquoted value.
+		e.prefix, e.rawPrefix, e.quote = wordPrefix(*e.line, e.cursor.Pos())
 
 	default:
 		e.prefix = completions.PREFIX
+		e.rawPrefix = completions.PREFIX
+		e.quote = 0
 	}
 }
 
 func (e *Engine) setSuffix(completions Values) {
 	switch completions.SUFFIX {
 	case "":
-		cpos := e.cursor.Pos()
-		_, epos := e.line.SelectBlankWord(cpos)
-
-		// Safety checks and adjustments.
-		if epos < e.line.Len() {
-			epos++
-		}
-
-		if epos < cpos {
-			epos, cpos = cpos, epos
-		}
-
-		// Add back single or double quotes in the character after epos is one of them.
-		if epos < e.line.Len() {
-			if (*e.line)[epos] == '\'' || (*e.line)[epos] == '"' {
-				epos++
-			}
-		}
-
-		e.suffix = strings.TrimSpace(string((*e.line)[cpos:epos]))
+		e.suffix = wordSuffix(*e.line, e.cursor.Pos())
 
 	default:
 		e.suffix = completions.SUFFIX
@@ -120,13 +107,20 @@ func (e *Engine) generateGroup(comps Values) func(tag string, values RawValues)
 		// those which don't, and devise if there are aliases.
 		vals, noDescVals, descriptions := e.groupNonDescribed(&comps, values)
 
+		// Approximate matches are displayed under their number of errors,
+		// but keep the options of their tag when inserted.
+		heading := tag
+		if len(values) > 0 && values[0].errors > 0 {
+			heading = correctionsTag(values[0].errors)
+		}
+
 		// Create a "first" group with the "first" grouped values
-		e.newCompletionGroup(comps, tag, vals, descriptions)
+		e.newCompletionGroup(comps, tag, heading, vals, descriptions)
 
 		// If we have a remaining group of values without descriptions,
 		// we will print and use them in a separate, anonymous group.
 		if len(noDescVals) > 0 {
-			e.newCompletionGroup(comps, "", noDescVals, descriptions)
+			e.newCompletionGroup(comps, "", "", noDescVals, descriptions)
 		}
 	}
 }
@@ -254,7 +248,7 @@ func (e *Engine) justifyGroups(values Values) {
 
 	for _, group := range e.groups {
 		// Skip groups that are not to be justified
-		justify := values.Pad[group.tag]
+		justify := values.Pad[group.valuesTag]
 		if !justify {
 			justify = values.Pad["*"]
 		}
@@ -391,6 +385,8 @@ func (e *Engine) resetValues(comps, cached bool) {
 	if comps {
 		e.usedY = 0
 		e.groups = make([]*group, 0)
+		e.marked = nil
+		e.menuOffset = 0
 	}
 
 	// Drop the completion generation function.
@@ -470,6 +466,93 @@ func (e *Engine) getAbsPos() int {
 	return prev
 }
 
+// menuRows returns the number of terminal rows needed to display
+// all completion groups, including their tags, if any.
+func (e *Engine) menuRows() (rows int) {
+	for _, grp := range e.groups {
+		if len(grp.rows) == 0 {
+			continue
+		}
+
+		if grp.tag != "" {
+			rows++
+		}
+
+		rows += len(grp.rows)
+	}
+
+	return rows
+}
+
+// scrollMenu adjusts the scroll offset of the completion menu, so that the row
+// of the selected candidate is visible in a window of a given number of rows.
+// The offset only changes when the selector goes out of this window.
+func (e *Engine) scrollMenu(visible int) {
+	absPos := e.getAbsPos()
+
+	if absPos < e.menuOffset {
+		e.menuOffset = absPos
+	} else if absPos >= e.menuOffset+visible {
+		e.menuOffset = absPos - visible + 1
+	}
+
+	// When the selector goes back to a first candidate
+	// under a tag, make the tag visible if possible.
+	if cur := e.currentGroup(); cur != nil && cur.tag != "" && cur.posY <= 0 && absPos > 0 && visible > 1 {
+		if e.menuOffset == absPos {
+			e.menuOffset--
+		}
+	}
+
+	if maxOffset := e.menuRows() - visible; e.menuOffset > maxOffset {
+		e.menuOffset = maxOffset
+	}
+
+	if e.menuOffset < 0 {
+		e.menuOffset = 0
+	}
+}
+
+// menuPosition returns a position indicator for the completion menu,
+// with the index of the selected candidate (if any) over the number
+// of candidates, and the range of rows currently displayed.
+func (e *Engine) menuPosition(visible, total int) string {
+	comps, _ := e.completionCount()
+
+	index := "-"
+	if len(e.selected.Value) > 0 {
+		index = strconv.Itoa(e.selectedIndex())
+	}
+
+	position := fmt.Sprintf(" %s/%d (rows %d-%d of %d)", index, comps, e.menuOffset+1, e.menuOffset+visible, total)
+
+	return color.Dim + color.FgYellow + position + color.Reset
+}
+
+// selectedIndex returns the position (starting at 1) of the selected
+// candidate among all candidates, in the order in which they are displayed.
+func (e *Engine) selectedIndex() int {
+	var index int
+
+	for _, grp := range e.groups {
+		if !grp.isCurrent {
+			for _, row := range grp.rows {
+				index += len(row)
+			}
+
+			continue
+		}
+
+		for y := 0; y < grp.posY && y < len(grp.rows); y++ {
+			index += len(grp.rows[y])
+		}
+
+		return index + grp.posX + 1
+	}
+
+	return index
+}
+
 func sum(vals []int) (sum int) {
 	for _, val := range vals {
 		sum += val
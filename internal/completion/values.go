@@ -43,22 +43,30 @@ func (c *Values) Merge(other Values) {
 }
 
 // EachTag iterates over each tag and runs a function for each group.
+// Approximate matches are grouped by tag and by number of errors.
 func (c RawValues) EachTag(tagF func(tag string, values RawValues)) {
-	tags := make([]string, 0)
-	tagGroups := make(map[string]RawValues)
+	type groupKey struct {
+		tag    string
+		errors int
+	}
+
+	keys := make([]groupKey, 0)
+	tagGroups := make(map[groupKey]RawValues)
 
 	for _, val := range c {
-		if _, exists := tagGroups[val.Tag]; !exists {
-			tagGroups[val.Tag] = make(RawValues, 0)
+		key := groupKey{val.Tag, val.errors}
+
+		if _, exists := tagGroups[key]; !exists {
+			tagGroups[key] = make(RawValues, 0)
 
-			tags = append(tags, val.Tag)
+			keys = append(keys, key)
 		}
 
-		tagGroups[val.Tag] = append(tagGroups[val.Tag], val)
+		tagGroups[key] = append(tagGroups[key], val)
 	}
 
-	for _, tag := range tags {
-		tagF(tag, tagGroups[tag])
+	for _, key := range keys {
+		tagF(key.tag, tagGroups[key])
 	}
 }
 
@@ -66,24 +74,19 @@ func (c RawValues) EachTag(tagF func(tag string, values RawValues)) {
 // If matchCase is false, the filtering is made case-insensitive.
 // This function ensures that all spaces are correctly.
 func (c RawValues) FilterPrefix(prefix string, matchCase bool) RawValues {
+	return c.filterPrefix(prefix, caseMatcher{ignoreCase: !matchCase})
+}
+
+// filterPrefix filters values with given prefix, according to a case matcher.
+func (c RawValues) filterPrefix(prefix string, matcher caseMatcher) RawValues {
 	if prefix == "" {
 		return c
 	}
 
 	filtered := make(RawValues, 0)
 
-	if !matchCase {
-		prefix = strings.ToLower(prefix)
-	}
-
 	for _, raw := range c {
-		val := raw.Value
-
-		if !matchCase {
-			val = strings.ToLower(val)
-		}
-
-		if strings.HasPrefix(val, prefix) {
+		if matcher.hasPrefix(raw.Value, prefix) {
 			filtered = append(filtered, raw)
 		}
 	}
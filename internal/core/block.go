@@ -0,0 +1,364 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// VisualBlock sets the selection as a visual block one (highlighted): the
+// selection is the rectangle of text between the lines and columns of its
+// mark and of the cursor, as seen with Ctrl-V in Vim. Like in Vim, columns
+// are display columns: tabs and wide characters span several of them.
+func (s *Selection) VisualBlock() {
+	s.visual = true
+	s.visualLine = false
+	s.visualBlock = true
+}
+
+// IsVisualBlock indicates whether the selection is a visual block one.
+func (s *Selection) IsVisualBlock() bool {
+	return s.visual && s.visualBlock
+}
+
+// ExtendBlockToEnd extends the visual block selection to the end of each of
+// its lines, whatever their length, until the cursor moves within its line.
+func (s *Selection) ExtendBlockToEnd() {
+	if !s.IsVisualBlock() {
+		return
+	}
+
+	s.blockEnd = true
+	s.blockRow, _ = s.line.rowCol(s.cursor.Pos())
+}
+
+// CheckBlockEnd should be called after each command: it stops extending the visual
+// block to the end of its lines if the cursor has moved away from the end of its line.
+func (s *Selection) CheckBlockEnd() {
+	if !s.blockEnd || !s.IsVisualBlock() {
+		return
+	}
+
+	row, col := s.line.rowCol(s.cursor.Pos())
+	starts := s.line.rowStarts()
+
+	switch {
+	case row != s.blockRow:
+		s.blockRow = row
+	case col < s.line.rowEnd(starts, row)-starts[row]-1:
+		s.blockEnd = false
+	}
+}
+
+// Rows returns the regions of each line covered by the visual block selection,
+// from top to bottom. Lines too short to reach the block have no region.
+// Regions are visual selections, and their end position is inclusive.
+func (s *Selection) Rows() []Selection {
+	top, bottom, left, right, valid := s.blockBounds()
+	if !valid {
+		return nil
+	}
+
+	rows := make([]Selection, 0, bottom-top+1)
+	starts := s.line.rowStarts()
+
+	for row := top; row <= bottom; row++ {
+		start := starts[row]
+		cols := s.line.rowColumns(starts, row)
+		length := len(cols) - 1
+
+		first := columnPos(cols, left)
+		if first >= length {
+			continue
+		}
+
+		last := length - 1
+		if !s.blockEnd {
+			last = min(columnPos(cols, right), last)
+		}
+
+		rows = append(rows, Selection{
+			Type:   "visual",
+			active: true,
+			visual: true,
+			bpos:   start + first,
+			epos:   start + last,
+			fg:     s.fg,
+			bg:     s.bg,
+			line:   s.line,
+			cursor: s.cursor,
+		})
+	}
+
+	return rows
+}
+
+// InsertBlock returns a block insertion for the visual block selection, and resets it:
+// text inserted at the returned position on the first line of the block is replicated
+// on all of its lines with BlockInsert.Apply(). If after is true, text is inserted after
+// the block (padding short lines with spaces), otherwise before it.
+// If the selection is not a visual block one, nil is returned.
+func (s *Selection) InsertBlock(after bool) *BlockInsert {
+	defer s.Reset()
+
+	return s.insertBlock(after)
+}
+
+// ChangeBlock deletes the visual block selection, and returns the deleted text,
+// and a block insertion at the deleted block position (see InsertBlock()).
+func (s *Selection) ChangeBlock() (buf string, insert *BlockInsert) {
+	insert = s.insertBlock(false)
+	if insert == nil {
+		return "", nil
+	}
+
+	buf = s.Cut()
+	insert.length = s.line.Len()
+
+	return buf, insert
+}
+
+// BlockInsert replicates on all lines of a visual block the text
+// inserted on its first line, as done with I/A/c in Vim visual block mode.
+type BlockInsert struct {
+	line   *Line
+	pos    int   // Insertion position on the first line.
+	length int   // Length of the line before insertion.
+	rows   []int // Other lines of the block.
+	column int   // Insertion display column on other lines, or -1 for their end.
+	pad    bool  // Pad lines narrower than the column with spaces.
+}
+
+// Pos returns the position at which text should be inserted on the first line.
+func (b *BlockInsert) Pos() int {
+	return b.pos
+}
+
+// Apply inserts the text inserted on the first line since the block insertion
+// started on all other lines of the block. Nothing is done if the text inserted
+// spans several lines, or if the line is shorter than it was.
+func (b *BlockInsert) Apply() {
+	inserted := b.line.Len() - b.length
+	if inserted <= 0 || b.pos+inserted > b.line.Len() {
+		return
+	}
+
+	text := make([]rune, inserted)
+	copy(text, (*b.line)[b.pos:b.pos+inserted])
+
+	if strings.ContainsRune(string(text), inputrc.Newline) {
+		return
+	}
+
+	starts := b.line.rowStarts()
+
+	// Bottom to top, so that line positions remain valid.
+	for i := len(b.rows) - 1; i >= 0; i-- {
+		row := b.rows[i]
+		if row >= len(starts) {
+			continue
+		}
+
+		pos, missing := b.line.rowEnd(starts, row), 0
+		if b.column != -1 {
+			pos, missing = b.line.insertPos(starts, row, b.column)
+		}
+
+		switch {
+		case missing > 0 && !b.pad:
+			continue
+		case missing > 0:
+			b.line.Insert(pos, []rune(strings.Repeat(" ", missing))...)
+			pos += missing
+		}
+
+		b.line.Insert(pos, text...)
+	}
+}
+
+func (s *Selection) insertBlock(after bool) *BlockInsert {
+	top, bottom, left, right, valid := s.blockBounds()
+	if !valid {
+		return nil
+	}
+
+	starts := s.line.rowStarts()
+
+	insert := &BlockInsert{
+		line:   s.line,
+		column: left,
+	}
+
+	for row := top + 1; row <= bottom; row++ {
+		insert.rows = append(insert.rows, row)
+	}
+
+	switch {
+	case after && s.blockEnd:
+		insert.column = -1
+		insert.pos = s.line.rowEnd(starts, top)
+	case after:
+		insert.column = right + 1
+		insert.pad = true
+
+		// The first line might be too short as well.
+		pos, missing := s.line.insertPos(starts, top, insert.column)
+		s.line.Insert(pos, []rune(strings.Repeat(" ", missing))...)
+		insert.pos = pos + missing
+	default:
+		insert.pos, _ = s.line.insertPos(starts, top, left)
+	}
+
+	insert.length = s.line.Len()
+
+	return insert
+}
+
+// blockText returns the text of each line of the visual block, one per line.
+func (s *Selection) blockText() string {
+	rows := s.Rows()
+	text := make([]string, 0, len(rows))
+
+	for _, row := range rows {
+		text = append(text, string((*s.line)[row.bpos:row.epos+1]))
+	}
+
+	return strings.Join(text, string(inputrc.Newline))
+}
+
+// blockCut deletes the visual block from the line, and returns its text.
+func (s *Selection) blockCut() string {
+	buf := s.blockText()
+	rows := s.Rows()
+
+	for i := len(rows) - 1; i >= 0; i-- {
+		s.line.Cut(rows[i].bpos, rows[i].epos+1)
+	}
+
+	return buf
+}
+
+// blockCursor returns the position of the top-left corner of the block.
+func (s *Selection) blockCursor() int {
+	top, _, left, _, valid := s.blockBounds()
+	if !valid {
+		return s.cursor.Pos()
+	}
+
+	starts := s.line.rowStarts()
+
+	return starts[top] + columnPos(s.line.rowColumns(starts, top), left)
+}
+
+// blockBounds returns the first and last lines, and the first and last display
+// columns (inclusive) of the visual block between the mark and the cursor.
+// Like in Vim, the block covers all columns of its corner characters.
+func (s *Selection) blockBounds() (top, bottom, left, right int, valid bool) {
+	if !s.active || !s.IsVisualBlock() || s.line.Len() == 0 || s.bpos < 0 {
+		return
+	}
+
+	mark := min(s.bpos, s.line.Len())
+
+	top, markLeft, markRight := s.line.displayCols(mark)
+	bottom, curLeft, curRight := s.line.displayCols(s.cursor.Pos())
+
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+
+	left, right = min(markLeft, curLeft), max(markRight, curRight)
+
+	return top, bottom, left, right, true
+}
+
+// displayCols returns the line of a position, and the first and last display
+// columns of its character (the end of a line is a single column after it).
+func (l *Line) displayCols(pos int) (row, first, last int) {
+	starts := l.rowStarts()
+	row, col := l.rowCol(pos)
+	cols := l.rowColumns(starts, row)
+
+	if col+1 >= len(cols) {
+		return row, cols[col], cols[col]
+	}
+
+	return row, cols[col], max(cols[col], cols[col+1]-1)
+}
+
+// rowColumns returns the display column at which each character of a line
+// starts, followed by the display width of the line. Tabs are displayed as
+// spaces, and wide characters use two columns.
+func (l *Line) rowColumns(starts []int, row int) []int {
+	start, end := starts[row], l.rowEnd(starts, row)
+	cols := make([]int, 1, end-start+1)
+
+	for pos := start; pos < end; pos++ {
+		cols = append(cols, cols[len(cols)-1]+strutil.RealLength(string((*l)[pos])))
+	}
+
+	return cols
+}
+
+// insertPos returns the position of the first character of a line displayed at or
+// after the display column col, for inserting text at this column. If the line is
+// narrower, its end is returned, with the number of missing columns.
+func (l *Line) insertPos(starts []int, row, col int) (pos, missing int) {
+	cols := l.rowColumns(starts, row)
+
+	for i, start := range cols {
+		if start >= col {
+			return starts[row] + i, 0
+		}
+	}
+
+	return starts[row] + len(cols) - 1, col - cols[len(cols)-1]
+}
+
+// columnPos returns the index of the first character whose display columns
+// include col or are after it, or the line length if it is narrower than col.
+func columnPos(cols []int, col int) int {
+	for i := 0; i < len(cols)-1; i++ {
+		if cols[i+1] > col {
+			return i
+		}
+	}
+
+	return len(cols) - 1
+}
+
+// rowStarts returns the position of the beginning of each line.
+func (l *Line) rowStarts() []int {
+	starts := []int{0}
+
+	for pos, char := range *l {
+		if char == inputrc.Newline {
+			starts = append(starts, pos+1)
+		}
+	}
+
+	return starts
+}
+
+// rowEnd returns the position of the end of a line (its newline, or the end of the buffer).
+func (l *Line) rowEnd(starts []int, row int) int {
+	if row+1 < len(starts) {
+		return starts[row+1] - 1
+	}
+
+	return l.Len()
+}
+
+// rowCol returns the line and column of a position.
+func (l *Line) rowCol(pos int) (row, col int) {
+	starts := l.rowStarts()
+
+	for row = len(starts) - 1; row > 0; row-- {
+		if starts[row] <= pos {
+			break
+		}
+	}
+
+	return row, pos - starts[row]
+}
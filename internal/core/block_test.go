@@ -0,0 +1,249 @@
+package core
+
+import (
+	"testing"
+	"unicode"
+)
+
+// Rows and columns of the test table:
+//
+//	name  | age | city
+//	alice | 30
+//	bob   | 4   | paris
+const blockTable = "name  | age | city\nalice | 30\nbob   | 4   | paris"
+
+// newBlock returns a visual block selection from
+// a (row, column) mark to a (row, column) cursor.
+func newBlock(text string, markRow, markCol, curRow, curCol int) (*Line, *Selection) {
+	line := Line([]rune(text))
+	cursor := NewCursor(&line)
+	starts := line.rowStarts()
+
+	sel := NewSelection(&line, cursor)
+	sel.Mark(starts[markRow] + markCol)
+	sel.VisualBlock()
+	cursor.Set(starts[curRow] + curCol)
+
+	return &line, sel
+}
+
+func TestSelection_Rows(t *testing.T) {
+	tests := []struct {
+		name  string
+		block [4]int
+		toEnd bool
+		want  string
+	}{
+		{name: "Single column", block: [4]int{0, 8, 2, 8}, want: "a\n3\n4"},
+		{name: "Inverted corners", block: [4]int{2, 10, 0, 8}, want: "age\n30\n4  "},
+		{name: "Short line skipped", block: [4]int{0, 14, 2, 15}, want: "ci\npa"},
+		{name: "Ragged line ends", block: [4]int{0, 8, 2, 8}, toEnd: true, want: "age | city\n30\n4   | paris"},
+		{name: "Single line", block: [4]int{1, 0, 1, 4}, want: "alice"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, sel := newBlock(blockTable, test.block[0], test.block[1], test.block[2], test.block[3])
+			if test.toEnd {
+				sel.ExtendBlockToEnd()
+			}
+
+			if got := sel.Text(); got != test.want {
+				t.Errorf("Selection.Text() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_RowsDisplayColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		block [4]int
+		want  string
+	}{
+		{name: "Wide characters", text: "日本 | x\nabcd | y", block: [4]int{0, 0, 1, 3}, want: "日本\nabcd"},
+		{name: "Wide character corner", text: "abcd\n日本", block: [4]int{0, 0, 1, 0}, want: "ab\n日"},
+		{name: "Partly covered wide character", text: "abcd\nx日本", block: [4]int{0, 1, 1, 0}, want: "ab\nx日"},
+		{name: "Tabs", text: "\tx\nabcdefg", block: [4]int{0, 1, 1, 5}, want: "x\nf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, sel := newBlock(test.text, test.block[0], test.block[1], test.block[2], test.block[3])
+
+			if got := sel.Text(); got != test.want {
+				t.Errorf("Selection.Text() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_CheckBlockEnd(t *testing.T) {
+	line, sel := newBlock(blockTable, 0, 8, 0, 17)
+	sel.ExtendBlockToEnd()
+
+	// Moving to another line keeps the extension.
+	sel.cursor.Set(line.rowStarts()[1] + 7)
+	sel.CheckBlockEnd()
+
+	if !sel.blockEnd {
+		t.Fatal("Selection.CheckBlockEnd(): block not extended after moving to another line")
+	}
+
+	// Moving within the line cancels it.
+	sel.cursor.Dec()
+	sel.CheckBlockEnd()
+
+	if sel.blockEnd {
+		t.Error("Selection.CheckBlockEnd(): block still extended after moving within the line")
+	}
+}
+
+func TestSelection_CutBlock(t *testing.T) {
+	line, sel := newBlock(blockTable, 0, 4, 2, 7)
+	cpos := sel.Cursor()
+
+	if got, want := sel.Cut(), "  | \ne | \n  | "; got != want {
+		t.Errorf("Selection.Cut() = %q, want %q", got, want)
+	}
+
+	if got, want := string(*line), "nameage | city\nalic30\nbob 4   | paris"; got != want {
+		t.Errorf("line = %q, want %q", got, want)
+	}
+
+	if cpos != 4 {
+		t.Errorf("Selection.Cursor() = %d, want %d", cpos, 4)
+	}
+
+	if sel.IsVisualBlock() {
+		t.Error("Selection.Cut(): selection not reset")
+	}
+}
+
+func TestSelection_ReplaceWithBlock(t *testing.T) {
+	line, sel := newBlock(blockTable, 0, 0, 2, 2)
+	sel.ReplaceWith(unicode.ToUpper)
+
+	if got, want := string(*line), "NAMe  | age | city\nALIce | 30\nBOB   | 4   | paris"; got != want {
+		t.Errorf("Selection.ReplaceWith() line = %q, want %q", got, want)
+	}
+}
+
+func TestSelection_InsertBlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		block  [4]int
+		after  bool
+		toEnd  bool
+		insert string
+		want   string
+	}{
+		{
+			name:   "Insert before",
+			block:  [4]int{0, 0, 2, 1},
+			insert: "# ",
+			want:   "# name  | age | city\n# alice | 30\n# bob   | 4   | paris",
+		},
+		{
+			name:   "Insert before skips short lines",
+			block:  [4]int{0, 13, 2, 13},
+			insert: "X",
+			want:   "name  | age |X city\nalice | 30\nbob   | 4   |X paris",
+		},
+		{
+			name:   "Append pads short lines",
+			block:  [4]int{0, 12, 2, 12},
+			after:  true,
+			insert: ":",
+			want:   "name  | age |: city\nalice | 30   :\nbob   | 4   |: paris",
+		},
+		{
+			name:   "Append to ragged ends",
+			block:  [4]int{0, 0, 2, 0},
+			after:  true,
+			toEnd:  true,
+			insert: ";",
+			want:   "name  | age | city;\nalice | 30;\nbob   | 4   | paris;",
+		},
+		{
+			name:   "Insert before wide characters",
+			text:   "ab|\n日|",
+			block:  [4]int{0, 2, 1, 1},
+			insert: "X",
+			want:   "abX|\n日X|",
+		},
+		{
+			name:   "Append after wide characters",
+			text:   "日本|\nabcd|",
+			block:  [4]int{0, 0, 1, 3},
+			after:  true,
+			insert: "X",
+			want:   "日本X|\nabcdX|",
+		},
+		{
+			name:   "Append pads narrow lines",
+			text:   "日本|\nab",
+			block:  [4]int{0, 1, 1, 1},
+			after:  true,
+			insert: "X",
+			want:   "日本X|\nab  X",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := blockTable
+			if test.text != "" {
+				text = test.text
+			}
+
+			line, sel := newBlock(text, test.block[0], test.block[1], test.block[2], test.block[3])
+			if test.toEnd {
+				sel.ExtendBlockToEnd()
+			}
+
+			block := sel.InsertBlock(test.after)
+			line.Insert(block.Pos(), []rune(test.insert)...)
+			block.Apply()
+
+			if got := string(*line); got != test.want {
+				t.Errorf("BlockInsert.Apply() line = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_ChangeBlock(t *testing.T) {
+	line, sel := newBlock(blockTable, 0, 8, 2, 10)
+
+	cut, block := sel.ChangeBlock()
+	if want := "age\n30\n4  "; cut != want {
+		t.Errorf("Selection.ChangeBlock() = %q, want %q", cut, want)
+	}
+
+	line.Insert(block.Pos(), []rune("n")...)
+	block.Apply()
+
+	if got, want := string(*line), "name  | n | city\nalice | n\nbob   | n | paris"; got != want {
+		t.Errorf("BlockInsert.Apply() line = %q, want %q", got, want)
+	}
+
+	// Multiline insertions are not replicated.
+	line, sel = newBlock(blockTable, 0, 0, 1, 0)
+	block = sel.InsertBlock(false)
+	line.Insert(block.Pos(), []rune("a\nb")...)
+	block.Apply()
+
+	if got, want := string(*line), "a\nbname  | age | city\nalice | 30\nbob   | 4   | paris"; got != want {
+		t.Errorf("BlockInsert.Apply() line = %q, want %q", got, want)
+	}
+
+	// An invalid block (like on an empty line) has no insertion.
+	_, sel = newBlock("", 0, 0, 0, 0)
+
+	if cut, block := sel.ChangeBlock(); cut != "" || block != nil {
+		t.Errorf("Selection.ChangeBlock() on empty line = %q, %v, want no block", cut, block)
+	}
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"slices"
+
+	"github.com/reeflective/readline/internal/color"
+)
+
+// AddCursor adds a cursor at pos in the line, in addition to the main one. The edits done
+// at the main cursor are replicated at all cursors (see UpdateCursors). Nothing is added if
+// the position is out of the line bounds or if there already is a cursor at this position.
+func (s *Selection) AddCursor(pos int) {
+	if pos < 0 || pos > s.line.Len() || pos == s.cursor.Pos() || slices.Contains(s.cursors, pos) {
+		return
+	}
+
+	if len(s.cursors) == 0 {
+		s.syncCursors()
+	}
+
+	s.cursors = append(s.cursors, pos)
+}
+
+// AddCursorNextMatch adds a cursor at the next occurrence of the word under (or just
+// before) the main cursor, after the last cursor added, and at the same offset in the
+// word as the main cursor. Words are those of Line.SelectWord, and occurrences must be
+// whole words. The search wraps around the line, and skips occurrences already having
+// a cursor. If the cursor is not on a word or if there is no other occurrence of it,
+// no cursor is added and false is returned.
+func (s *Selection) AddCursorNextMatch() bool {
+	pos := s.cursor.Pos()
+
+	if pos == s.line.Len() || wordClass((*s.line)[pos]) == blankClass {
+		if pos == 0 || wordClass((*s.line)[pos-1]) == blankClass {
+			return false
+		}
+
+		pos--
+	}
+
+	bpos, epos := s.line.SelectWord(pos)
+	word := (*s.line)[bpos : epos+1]
+	offset := s.cursor.Pos() - bpos
+
+	var candidates []int
+
+	for start := 0; start+len(word) <= s.line.Len(); start++ {
+		if !slices.Equal((*s.line)[start:start+len(word)], word) {
+			continue
+		}
+
+		if wbpos, wepos := s.line.SelectWord(start); wbpos != start || wepos != start+len(word)-1 {
+			continue
+		}
+
+		candidate := start + offset
+		if candidate != s.cursor.Pos() && !slices.Contains(s.cursors, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return false
+	}
+
+	// Occurrences are searched from the last cursor added.
+	last := s.cursor.Pos()
+	if len(s.cursors) > 0 {
+		last = s.cursors[len(s.cursors)-1]
+	}
+
+	next := candidates[0]
+
+	for _, candidate := range candidates {
+		if candidate > last {
+			next = candidate
+			break
+		}
+	}
+
+	s.AddCursor(next)
+
+	return true
+}
+
+// Cursors returns the positions of the additional
+// cursors in the line, in the order they were added.
+func (s *Selection) Cursors() []int {
+	return s.cursors
+}
+
+// ResetCursors removes all cursors but the main one.
+func (s *Selection) ResetCursors() {
+	s.cursors = nil
+}
+
+// UpdateCursors replicates at all cursors the last edit done at the main cursor,
+// and updates their positions accordingly. It must be called after each command.
+// If the line was changed elsewhere than at the main cursor (like when walking
+// the history or undoing changes), all additional cursors are removed.
+func (s *Selection) UpdateCursors() {
+	defer s.syncCursors()
+
+	if len(s.cursors) == 0 || slices.Equal(*s.line, s.cursorsLine) {
+		return
+	}
+
+	old := s.cursorsLine
+
+	start, oldEnd, newEnd, found := anchorEdit(old, *s.line, s.cursorsPos, s.cursor.Pos())
+	if !found {
+		s.cursors = nil
+		return
+	}
+
+	// The edit, relative to the position of the main cursor before it.
+	text := slices.Clone((*s.line)[start:newEnd])
+	from, to := start-s.cursorsPos, oldEnd-s.cursorsPos
+	offset := s.cursor.Pos() - start
+
+	type edit struct {
+		bpos, epos int
+		cursor     int // Index of the cursor, or -1 for the main one.
+	}
+
+	edits := []edit{{start, oldEnd, -1}}
+
+	for i, pos := range s.cursors {
+		bpos, epos := pos+from, pos+to
+
+		// Edits overlapping the main one or out of the line are dropped.
+		if pos == s.cursorsPos || bpos < 0 || epos > len(old) || (bpos < oldEnd && epos > start) {
+			continue
+		}
+
+		edits = append(edits, edit{bpos, epos, i})
+	}
+
+	slices.SortStableFunc(edits, func(a, b edit) int { return a.bpos - b.bpos })
+
+	var line []rune
+
+	cursors := make([]int, len(s.cursors))
+	for i := range cursors {
+		cursors[i] = -1
+	}
+
+	main, last := 0, 0
+
+	for _, edit := range edits {
+		// Edits overlapping a previous one are dropped.
+		if edit.bpos < last {
+			continue
+		}
+
+		line = append(line, old[last:edit.bpos]...)
+
+		if edit.cursor == -1 {
+			main = len(line) + offset
+		} else {
+			cursors[edit.cursor] = len(line) + offset
+		}
+
+		line = append(line, text...)
+		last = edit.epos
+	}
+
+	line = append(line, old[last:]...)
+
+	s.line.Set(line...)
+	s.cursor.Set(main)
+
+	s.cursors = nil
+	for _, pos := range cursors {
+		s.AddCursor(pos)
+	}
+}
+
+// MoveCursors runs a cursor motion at each of the additional cursors, as if each
+// of them was the main one. Cursors ending up on the same position are merged.
+func (s *Selection) MoveCursors(motion func()) {
+	if len(s.cursors) == 0 {
+		return
+	}
+
+	defer s.syncCursors()
+
+	pos := s.cursor.Pos()
+	moved := make([]int, 0, len(s.cursors))
+
+	for _, cursor := range s.cursors {
+		s.cursor.Set(cursor)
+		motion()
+		moved = append(moved, s.cursor.Pos())
+	}
+
+	s.cursor.Set(pos)
+
+	s.cursors = nil
+	for _, cursor := range moved {
+		s.AddCursor(cursor)
+	}
+}
+
+// HighlightCursors adds highlighting to all additional cursors in the line.
+func HighlightCursors(sel *Selection) {
+	for _, pos := range sel.cursors {
+		if pos >= sel.line.Len() {
+			continue
+		}
+
+		sel.surrounds = append(sel.surrounds, Selection{
+			Type:   "cursor",
+			active: true,
+			visual: true,
+			bpos:   pos,
+			epos:   pos,
+			bg:     color.Reverse,
+			line:   sel.line,
+			cursor: sel.cursor,
+		})
+	}
+}
+
+// ResetCursorsHighlight is used by the display
+// engine to reset cursors highlighting regions.
+func ResetCursorsHighlight(sel *Selection) {
+	var surrounds []Selection
+
+	for _, surround := range sel.surrounds {
+		if surround.Type == "cursor" {
+			continue
+		}
+
+		surrounds = append(surrounds, surround)
+	}
+
+	sel.surrounds = surrounds
+}
+
+// SyncCursors checks the positions of all additional cursors like Cursor.CheckCommand
+// (in Vim command mode) or Cursor.CheckAppend, and stores the line and main cursor
+// position against which the next edit is compared for replication. It must be called
+// after the main cursor position is checked, since edits are anchored at it.
+func (s *Selection) SyncCursors(command bool) {
+	if len(s.cursors) == 0 {
+		return
+	}
+
+	cursors := s.cursors
+	s.cursors = nil
+
+	for _, pos := range cursors {
+		cursor := NewCursor(s.line)
+		cursor.Set(pos)
+
+		if command {
+			cursor.CheckCommand()
+		}
+
+		s.AddCursor(cursor.Pos())
+	}
+
+	s.syncCursors()
+}
+
+// syncCursors stores the line and main cursor position
+// against which the next edit is compared for replication.
+func (s *Selection) syncCursors() {
+	s.cursorsLine = slices.Clone(*s.line)
+	s.cursorsPos = s.cursor.Pos()
+}
+
+// anchorEdit returns the range of a change between two lines, both in the old line
+// (start to oldEnd) and the new one (start to newEnd). When the change is ambiguous
+// (like inserting a character next to the same one), the range is chosen so that it
+// is anchored at the cursor positions before (prev) and after (pos) the change.
+func anchorEdit(old, line Line, prev, pos int) (start, oldEnd, newEnd int, found bool) {
+	start, oldEnd, newEnd = lineDiff(old, line)
+
+	for {
+		if start <= prev && prev <= oldEnd && start <= pos && pos <= newEnd {
+			return start, oldEnd, newEnd, true
+		}
+
+		if start == 0 {
+			return start, oldEnd, newEnd, false
+		}
+
+		// The change can only slide back over identical characters.
+		if newEnd > start && line[start-1] != line[newEnd-1] {
+			return start, oldEnd, newEnd, false
+		}
+
+		if oldEnd > start && old[start-1] != old[oldEnd-1] {
+			return start, oldEnd, newEnd, false
+		}
+
+		start, oldEnd, newEnd = start-1, oldEnd-1, newEnd-1
+	}
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelection_AddCursorNextMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		cursor  int
+		times   int
+		want    []int
+		success bool
+	}{
+		{name: "Next occurrence", line: "foo bar foo", cursor: 1, times: 1, want: []int{9}, success: true},
+		{name: "Whole words only", line: "foo foobar foo", cursor: 0, times: 1, want: []int{11}, success: true},
+		{name: "Wraps around", line: "foo bar foo foo", cursor: 8, times: 2, want: []int{12, 0}, success: true},
+		{name: "After the word", line: "foo bar foo", cursor: 3, times: 1, want: []int{11}, success: true},
+		{name: "No other occurrence", line: "foo bar", cursor: 0, times: 1},
+		{name: "All occurrences taken", line: "foo foo", cursor: 0, times: 2, want: []int{4}},
+		{name: "Not on a word", line: "foo  foo", cursor: 4, times: 1},
+		{name: "Non-ASCII word", line: "café bar café", cursor: 1, times: 1, want: []int{10}, success: true},
+		{name: "Punctuation word", line: "a -- b --", cursor: 2, times: 1, want: []int{7}, success: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			cursor := NewCursor(&line)
+			cursor.Set(test.cursor)
+			sel := NewSelection(&line, cursor)
+
+			var success bool
+			for range test.times {
+				success = sel.AddCursorNextMatch()
+			}
+
+			if success != test.success {
+				t.Errorf("Selection.AddCursorNextMatch() = %v, want %v", success, test.success)
+			}
+
+			if got := sel.Cursors(); !slices.Equal(got, test.want) {
+				t.Errorf("Selection.Cursors() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_UpdateCursors(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		cursor     int
+		cursors    []int
+		edit       func(line *Line, cursor *Cursor)
+		wantLine   string
+		wantCursor int
+		want       []int
+	}{
+		{
+			name: "Insert", line: "foo bar foo", cursor: 3, cursors: []int{11},
+			edit: func(line *Line, cursor *Cursor) {
+				line.Insert(cursor.Pos(), 'd')
+				cursor.Inc()
+			},
+			wantLine: "food bar food", wantCursor: 4, want: []int{13},
+		},
+		{
+			name: "Insert after same character", line: "aa b aa", cursor: 2, cursors: []int{7},
+			edit: func(line *Line, cursor *Cursor) {
+				line.Insert(cursor.Pos(), 'a')
+				cursor.Inc()
+			},
+			wantLine: "aaa b aaa", wantCursor: 3, want: []int{9},
+		},
+		{
+			name: "Backward delete", line: "foo bar foo", cursor: 3, cursors: []int{11},
+			edit: func(line *Line, cursor *Cursor) {
+				line.Cut(cursor.Pos()-1, cursor.Pos())
+				cursor.Dec()
+			},
+			wantLine: "fo bar fo", wantCursor: 2, want: []int{9},
+		},
+		{
+			name: "Cursor before main", line: "foo bar foo", cursor: 11, cursors: []int{3},
+			edit: func(line *Line, cursor *Cursor) {
+				line.Insert(cursor.Pos(), 'x', 'y')
+				cursor.Move(2)
+			},
+			wantLine: "fooxy bar fooxy", wantCursor: 15, want: []int{5},
+		},
+		{
+			name: "Adjacent deletions merge cursors", line: "ab", cursor: 2, cursors: []int{1},
+			edit: func(line *Line, cursor *Cursor) {
+				line.Cut(cursor.Pos()-1, cursor.Pos())
+				cursor.Dec()
+			},
+			wantLine: "", wantCursor: 0,
+		},
+		{
+			name: "Edit away from the main cursor", line: "foo bar foo", cursor: 3, cursors: []int{11},
+			edit: func(line *Line, _ *Cursor) {
+				line.Set([]rune("baz")...)
+			},
+			wantLine: "baz", wantCursor: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			cursor := NewCursor(&line)
+			cursor.Set(test.cursor)
+			sel := NewSelection(&line, cursor)
+
+			for _, pos := range test.cursors {
+				sel.AddCursor(pos)
+			}
+
+			test.edit(&line, cursor)
+			sel.UpdateCursors()
+
+			if string(line) != test.wantLine {
+				t.Errorf("Line = %q, want %q", string(line), test.wantLine)
+			}
+
+			if cursor.Pos() != test.wantCursor {
+				t.Errorf("Cursor.Pos() = %d, want %d", cursor.Pos(), test.wantCursor)
+			}
+
+			if got := sel.Cursors(); !slices.Equal(got, test.want) {
+				t.Errorf("Selection.Cursors() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_MoveCursors(t *testing.T) {
+	line := Line("foo bar foo bar")
+	cursor := NewCursor(&line)
+	sel := NewSelection(&line, cursor)
+
+	sel.AddCursor(8)
+	sel.AddCursor(9)
+
+	sel.MoveCursors(func() { cursor.Set(cursor.Pos() + line.Forward(line.Tokenize, cursor.Pos())) })
+
+	if got, want := sel.Cursors(), []int{12}; !slices.Equal(got, want) {
+		t.Errorf("Selection.Cursors() = %v, want %v", got, want)
+	}
+
+	if cursor.Pos() != 0 {
+		t.Errorf("Cursor.Pos() = %d, want 0", cursor.Pos())
+	}
+}
+
+func TestSelection_SyncCursors(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		cursor  int
+		cursors []int
+		command bool
+		want    []int
+	}{
+		{name: "Insert mode", line: "foo bar", cursor: 0, cursors: []int{7}, want: []int{7}},
+		{name: "Command mode end of line", line: "foo bar", cursor: 0, cursors: []int{7}, command: true, want: []int{6}},
+		{name: "Command mode newline", line: "foo\nbar", cursor: 0, cursors: []int{3}, command: true, want: []int{2}},
+		{name: "Merged with main cursor", line: "foo bar", cursor: 6, cursors: []int{7}, command: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			cursor := NewCursor(&line)
+			cursor.Set(test.cursor)
+			sel := NewSelection(&line, cursor)
+
+			for _, pos := range test.cursors {
+				sel.AddCursor(pos)
+			}
+
+			sel.SyncCursors(test.command)
+
+			if got := sel.Cursors(); !slices.Equal(got, test.want) {
+				t.Errorf("Selection.Cursors() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// KeywordMatcher is a named regular expression used to select keywords in the blank
+// word under the cursor (select-keyword-next/prev): its whole match is selected first,
+// then each of its capturing subgroups, before trying the next matcher.
+type KeywordMatcher struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// KeywordMatchers returns the built-in keyword matchers, in the order in which they are tried:
+// URI (host, path and parameters) / UUID / AWS ARN (partition, service, region, account and
+// resource) / Email address (user and domain) / Kubernetes resource (namespace/kind/name) /
+// File reference (file:line:column).
+func KeywordMatchers() []KeywordMatcher {
+	return []KeywordMatcher{
+		{"uri", regexp.MustCompile(`https?:\/\/(?:www\.)?([-a-zA-Z0-9@:%._\+~#=]{2,256}\.[a-z]{2,6}\b)*(\/[\/\d\w\.-]*)*(?:[\?])*(.+)*`)},
+		{"uuid", regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)},
+		{"arn", regexp.MustCompile(`arn:(aws[\w-]*):([\w-]+):([\w-]*):(\d{12})?:([\w/:.+=@,-]+)`)},
+		{"email", regexp.MustCompile(`([\w.%+-]+)@([\w-]+(?:\.[\w-]+)+)`)},
+		{"kubernetes", regexp.MustCompile(`^([a-z0-9][-a-z0-9]*)/([a-z][a-z0-9.]*)/([a-z0-9][-a-z0-9.]*)$`)},
+		{"file-line", regexp.MustCompile(`^((?:[\w.~-]*/)*[\w.-]+):(\d+)(?::(\d+))?`)},
+	}
+}
+
+// SetKeywordMatchers sets the keyword matchers used by SelectKeyword, in order.
+// If nil, the built-in matchers are used.
+func (s *Selection) SetKeywordMatchers(matchers []KeywordMatcher) {
+	sameNames := slices.EqualFunc(s.matchers, matchers, func(a, b KeywordMatcher) bool {
+		return a.Name == b.Name
+	})
+
+	if !sameNames {
+		s.kpos, s.kmpos = 0, 0
+	}
+
+	s.matchers = matchers
+}
+
+// keyword is a match (or a subgroup of it) of a keyword matcher.
+type keyword struct {
+	matcher, group int
+	bpos, epos     int
+}
+
+// matchKeyword returns the next (or previous) keyword in the buffer, among the whole
+// matches and subgroups of all matchers, in order. When not cycling yet, the whole match
+// of the first (or last) matcher succeeding is returned. Positions are offset by bbpos.
+func (s *Selection) matchKeyword(buf []rune, bbpos int, next bool) (name string, found bool, bpos, epos int) {
+	matchers := s.matchers
+	if matchers == nil {
+		matchers = KeywordMatchers()
+	}
+
+	var keywords []keyword
+
+	current, last := -1, -1
+
+	for i, matcher := range matchers {
+		for group, match := range runMatcher(buf, matcher.Pattern) {
+			// Don't select the same text twice in a row.
+			if len(keywords) > 0 && keywords[len(keywords)-1].matcher == i &&
+				keywords[len(keywords)-1].bpos == match[0] && keywords[len(keywords)-1].epos == match[1] {
+				continue
+			}
+
+			if group == 0 {
+				last = len(keywords)
+			}
+
+			if i+1 == s.kpos && group+1 == s.kmpos {
+				current = len(keywords)
+			}
+
+			keywords = append(keywords, keyword{i, group, match[0], match[1]})
+		}
+	}
+
+	switch {
+	case len(keywords) == 0:
+		return "", false, -1, -1
+	case current == -1 && next:
+		current = 0
+	case current == -1:
+		current = last
+	case next:
+		current = (current + 1) % len(keywords)
+	default:
+		current = (current - 1 + len(keywords)) % len(keywords)
+	}
+
+	match := keywords[current]
+	s.kpos, s.kmpos = match.matcher+1, match.group+1
+
+	return matchers[match.matcher].Name, true, bbpos + match.bpos, bbpos + match.epos
+}
+
+// runMatcher returns the positions (in runes) of the first match of a pattern in
+// the buffer, followed by those of its non-empty subgroups, or nil if none.
+func runMatcher(buf []rune, matcher *regexp.Regexp) (groups [][2]int) {
+	if matcher == nil {
+		return
+	}
+
+	text := string(buf)
+
+	indexes := matcher.FindStringSubmatchIndex(text)
+	if len(indexes) == 0 {
+		return
+	}
+
+	for i := 0; i < len(indexes); i += 2 {
+		if indexes[i] == -1 || indexes[i] == indexes[i+1] {
+			continue
+		}
+
+		bpos := utf8.RuneCountInString(text[:indexes[i]])
+		epos := bpos + utf8.RuneCountInString(text[indexes[i]:indexes[i+1]])
+		groups = append(groups, [2]int{bpos, epos})
+	}
+
+	return groups
+}
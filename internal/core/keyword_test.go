@@ -0,0 +1,87 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSelection_SelectKeywordMatchers(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		matchers []KeywordMatcher
+		next     bool
+		want     []string
+	}{
+		{
+			name: "UUID",
+			line: "id=123e4567-e89b-12d3-a456-426614174000",
+			next: true,
+			want: []string{"123e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{
+			name: "ARN",
+			line: "arn:aws:s3:::bucket/key",
+			next: true,
+			want: []string{"arn:aws:s3:::bucket/key", "aws", "s3", "bucket/key", "arn:aws:s3:::bucket/key"},
+		},
+		{
+			name: "Email backward",
+			line: "<john.doe@example.com>",
+			want: []string{"john.doe@example.com", "example.com", "john.doe", "john.doe@example.com"},
+		},
+		{
+			name: "Kubernetes resource",
+			line: "default/deployment/web-1",
+			next: true,
+			want: []string{"default/deployment/web-1", "default", "deployment", "web-1"},
+		},
+		{
+			name: "File reference",
+			line: "./cmd/main.go:42:7:",
+			next: true,
+			want: []string{"./cmd/main.go:42:7", "./cmd/main.go", "42", "7"},
+		},
+		{
+			name: "Custom matchers",
+			line: "JIRA-1234@v1.2",
+			matchers: []KeywordMatcher{
+				{"ticket", regexp.MustCompile(`([A-Z]+)-(\d+)`)},
+				{"version", regexp.MustCompile(`v\d+\.\d+`)},
+			},
+			next: true,
+			want: []string{"JIRA-1234", "JIRA", "1234", "v1.2", "JIRA-1234"},
+		},
+		{
+			name:     "No matchers",
+			line:     "default/deployment/web-1",
+			matchers: []KeywordMatcher{},
+			next:     true,
+			want:     []string{""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			sel := NewSelection(&line, NewCursor(&line))
+
+			if test.matchers != nil {
+				sel.SetKeywordMatchers(test.matchers)
+			}
+
+			for i, want := range test.want {
+				bpos, epos, found := sel.SelectKeyword(0, line.Len(), test.next)
+
+				var got string
+				if found {
+					got = string(line[bpos:epos])
+				}
+
+				if got != want {
+					t.Fatalf("step %d: Selection.SelectKeyword() = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
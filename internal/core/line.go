@@ -133,8 +133,9 @@ func (l *Line) Len() int {
 	return utf8.RuneCountInString(string(*l))
 }
 
-// SelectWord returns the begin and end index positions of a word
-// (separated by punctuation or spaces) around the specified position.
+// SelectWord returns the begin and end index positions of a word around the
+// specified position: a word is either a sequence of letters, digits and
+// underscores, a sequence of other non-blank characters, or of blanks.
 func (l *Line) SelectWord(pos int) (bpos, epos int) {
 	if l.Len() == 0 {
 		return bpos, epos
@@ -145,32 +146,15 @@ func (l *Line) SelectWord(pos int) (bpos, epos int) {
 		pos--
 	}
 
-	wordRgx := regexp.MustCompile("[0-9a-zA-Z_]")
+	class := wordClass((*l)[pos])
 	bpos, epos = pos, pos
 
-	if match := wordRgx.MatchString(string((*l)[pos])); !match {
-		wordRgx = regexp.MustCompile(`\s`)
+	for bpos > 0 && wordClass((*l)[bpos-1]) == class {
+		bpos--
 	}
 
-	// To first space found backward
-	for ; bpos >= 0; bpos-- {
-		if match := wordRgx.MatchString(string((*l)[bpos])); !match {
-			break
-		}
-	}
-
-	// And to first space found forward
-	for ; epos < l.Len(); epos++ {
-		if match := wordRgx.MatchString(string((*l)[epos])); !match {
-			break
-		}
-	}
-
-	bpos++
-
-	// Ending position must be greater than 0
-	if epos > 0 {
-		epos--
+	for epos < l.Len()-1 && wordClass((*l)[epos+1]) == class {
+		epos++
 	}
 
 	return bpos, epos
@@ -724,3 +708,21 @@ func (l *Line) checkPosRange(pos int) int {
 
 	return pos
 }
+
+// Classes of characters forming words (see Line.SelectWord).
+const (
+	blankClass = iota
+	wordCharClass
+	punctuationClass
+)
+
+func wordClass(char rune) int {
+	switch {
+	case unicode.IsSpace(char):
+		return blankClass
+	case char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char):
+		return wordCharClass
+	default:
+		return punctuationClass
+	}
+}
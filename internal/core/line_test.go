@@ -250,6 +250,7 @@ func TestLine_Len(t *testing.T) {
 
 func TestLine_SelectWord(t *testing.T) {
 	line := Line("basic -c true -p on")
+	unicodeLine := Line("un café -- crème")
 
 	type args struct {
 		pos int
@@ -296,6 +297,27 @@ func TestLine_SelectWord(t *testing.T) {
 			wantBpos: 5,
 			wantEpos: 5,
 		},
+		{
+			name:     "Select punctuation",
+			l:        &line,
+			args:     args{6},
+			wantBpos: 6,
+			wantEpos: 6,
+		},
+		{
+			name:     "Select non-ASCII word",
+			l:        &unicodeLine,
+			args:     args{6},
+			wantBpos: 3,
+			wantEpos: 6,
+		},
+		{
+			name:     "Select punctuation word",
+			l:        &unicodeLine,
+			args:     args{8},
+			wantBpos: 8,
+			wantEpos: 9,
+		},
 	}
 
 	for _, test := range tests {
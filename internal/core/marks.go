@@ -0,0 +1,226 @@
+package core
+
+import (
+	"slices"
+)
+
+// maxMarks is the maximum number of positions kept in the jumplist and in the change list.
+const maxMarks = 100
+
+// Marks keeps the named marks of the input line, and the lists of its jumps and changes,
+// as done in Vim. Their positions are kept in sync with the line by Update(), which should
+// be called after each command: it compares the line with its previous state, shifts the
+// positions after the text modified, and records the change.
+//
+// Besides marks a-z, the ` (or ') mark is the position before the last jump,
+// and the . mark is the position of the last change.
+type Marks struct {
+	line    *Line
+	last    Line
+	named   map[rune]int
+	jumps   []int
+	jump    int
+	changes []int
+	change  int
+}
+
+// NewMarks returns a new list of marks for the line.
+func NewMarks(line *Line) *Marks {
+	marks := &Marks{line: line}
+	marks.Reset()
+
+	return marks
+}
+
+// Set sets a mark at a position.
+func (m *Marks) Set(name rune, pos int) {
+	if name == '\'' {
+		name = '`'
+	}
+
+	m.named[name] = pos
+}
+
+// Get returns the position of a mark, if it is set.
+func (m *Marks) Get(name rune) (pos int, set bool) {
+	if name == '\'' {
+		name = '`'
+	}
+
+	pos, set = m.named[name]
+
+	return pos, set && pos <= m.line.Len()
+}
+
+// Names returns the names of all marks set, the jump
+// mark first, then named marks, then the last change mark.
+func (m *Marks) Names() []rune {
+	names := make([]rune, 0, len(m.named))
+
+	for name := range m.named {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b rune) int {
+		return markOrder(a) - markOrder(b)
+	})
+
+	return names
+}
+
+// Jump records a position before jumping away from it:
+// it is added to the jumplist, and is the ` mark.
+func (m *Marks) Jump(pos int) {
+	m.named['`'] = pos
+
+	m.jumps = slices.DeleteFunc(m.jumps, func(jump int) bool { return jump == pos })
+	m.jumps = append(m.jumps, pos)
+
+	if len(m.jumps) > maxMarks {
+		m.jumps = m.jumps[1:]
+	}
+
+	m.jump = len(m.jumps)
+}
+
+// JumpOlder returns the previous position in the jumplist. When walking it
+// from its end, the current position is recorded, so as to come back to it.
+func (m *Marks) JumpOlder(pos int) (int, bool) {
+	if m.jump == len(m.jumps) {
+		m.Jump(pos)
+		m.jump--
+	}
+
+	if m.jump == 0 {
+		return pos, false
+	}
+
+	m.jump--
+
+	return m.jumps[m.jump], true
+}
+
+// JumpNewer returns the next position in the jumplist.
+func (m *Marks) JumpNewer() (int, bool) {
+	if m.jump >= len(m.jumps)-1 {
+		return 0, false
+	}
+
+	m.jump++
+
+	return m.jumps[m.jump], true
+}
+
+// ChangeOlder returns the previous position in the change list.
+func (m *Marks) ChangeOlder() (int, bool) {
+	if m.change == 0 {
+		return 0, false
+	}
+
+	m.change--
+
+	return m.changes[m.change], true
+}
+
+// ChangeNewer returns the next position in the change list.
+func (m *Marks) ChangeNewer() (int, bool) {
+	if m.change >= len(m.changes)-1 {
+		return 0, false
+	}
+
+	m.change++
+
+	return m.changes[m.change], true
+}
+
+// Update compares the line with its state when last updated: if it has changed,
+// all positions after the text modified are shifted, and those within it are
+// moved to its beginning. The change is recorded in the change list, and is the
+// . mark. Successive changes on the same line are recorded as a single one.
+func (m *Marks) Update() {
+	if slices.Equal(*m.line, m.last) {
+		return
+	}
+
+	start, oldEnd, newEnd := lineDiff(m.last, *m.line)
+
+	shift := func(pos int) int {
+		switch {
+		case pos < start:
+			return pos
+		case pos >= oldEnd:
+			return pos + newEnd - oldEnd
+		default:
+			return start
+		}
+	}
+
+	for name, pos := range m.named {
+		m.named[name] = shift(pos)
+	}
+
+	for i, pos := range m.jumps {
+		m.jumps[i] = shift(pos)
+	}
+
+	for i, pos := range m.changes {
+		m.changes[i] = shift(pos)
+	}
+
+	m.last = append(m.last[:0], *m.line...)
+	m.named['.'] = start
+
+	// Record the change.
+	if count := len(m.changes); count > 0 {
+		row, _ := m.line.rowCol(m.changes[count-1])
+		if changeRow, _ := m.line.rowCol(start); row == changeRow {
+			m.changes = m.changes[:count-1]
+		}
+	}
+
+	m.changes = append(m.changes, start)
+
+	if len(m.changes) > maxMarks {
+		m.changes = m.changes[1:]
+	}
+
+	m.change = len(m.changes)
+}
+
+// Reset drops all marks, jumps and changes.
+func (m *Marks) Reset() {
+	m.last = append(m.last[:0], *m.line...)
+	m.named = make(map[rune]int)
+	m.jumps = nil
+	m.jump = 0
+	m.changes = nil
+	m.change = 0
+}
+
+// lineDiff returns the beginning of the text modified between two lines,
+// and its end in the old line and in the new one.
+func lineDiff(old, line Line) (start, oldEnd, newEnd int) {
+	for start < len(old) && start < len(line) && old[start] == line[start] {
+		start++
+	}
+
+	oldEnd, newEnd = len(old), len(line)
+
+	for oldEnd > start && newEnd > start && old[oldEnd-1] == line[newEnd-1] {
+		oldEnd--
+		newEnd--
+	}
+
+	return start, oldEnd, newEnd
+}
+
+func markOrder(name rune) int {
+	switch name {
+	case '`':
+		return 0
+	case '.':
+		return 1 << 16
+	default:
+		return int(name)
+	}
+}
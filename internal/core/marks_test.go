@@ -0,0 +1,127 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestMarks_Update(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(line *Line)
+		want map[rune]int
+	}{
+		{
+			name: "Insert before",
+			edit: func(line *Line) { line.Insert(0, []rune("# ")...) },
+			want: map[rune]int{'a': 6, 'b': 14, '.': 0},
+		},
+		{
+			name: "Insert at mark",
+			edit: func(line *Line) { line.Insert(4, 'x') },
+			want: map[rune]int{'a': 5, 'b': 13, '.': 4},
+		},
+		{
+			name: "Insert after",
+			edit: func(line *Line) { line.Insert(13, 'x') },
+			want: map[rune]int{'a': 4, 'b': 12, '.': 13},
+		},
+		{
+			name: "Cut before",
+			edit: func(line *Line) { line.Cut(0, 4) },
+			want: map[rune]int{'a': 0, 'b': 8, '.': 0},
+		},
+		{
+			name: "Cut around",
+			edit: func(line *Line) { line.Cut(2, 10) },
+			want: map[rune]int{'a': 2, 'b': 4, '.': 2},
+		},
+		{
+			name: "Replace line",
+			edit: func(line *Line) { line.Set([]rune("echo")...) },
+			want: map[rune]int{'a': 0, 'b': 0, '.': 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line([]rune("foo bar\nbaz qux"))
+			marks := NewMarks(&line)
+			marks.Set('a', 4)
+			marks.Set('b', 12)
+
+			test.edit(&line)
+			marks.Update()
+
+			for name, want := range test.want {
+				if pos, _ := marks.Get(name); pos != want {
+					t.Errorf("Marks.Get(%q) = %d, want %d", name, pos, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMarks_Jumps(t *testing.T) {
+	line := Line([]rune("0123456789"))
+	marks := NewMarks(&line)
+
+	marks.Jump(2)
+	marks.Jump(5)
+	marks.Jump(2)
+
+	steps := []struct {
+		name  string
+		run   func() (int, bool)
+		pos   int
+		found bool
+	}{
+		{name: "Older", run: func() (int, bool) { return marks.JumpOlder(8) }, pos: 2, found: true},
+		{name: "Older again", run: func() (int, bool) { return marks.JumpOlder(2) }, pos: 5, found: true},
+		{name: "Oldest", run: func() (int, bool) { return marks.JumpOlder(5) }, pos: 5},
+		{name: "Newer", run: marks.JumpNewer, pos: 2, found: true},
+		{name: "Back to start", run: marks.JumpNewer, pos: 8, found: true},
+		{name: "Newest", run: marks.JumpNewer},
+	}
+
+	for _, step := range steps {
+		pos, found := step.run()
+		if found != step.found || (found && pos != step.pos) {
+			t.Fatalf("%s: got (%d, %t), want (%d, %t)", step.name, pos, found, step.pos, step.found)
+		}
+	}
+
+	if pos, _ := marks.Get('\''); pos != 8 {
+		t.Errorf("Marks.Get('\\'') = %d, want %d", pos, 8)
+	}
+}
+
+func TestMarks_Changes(t *testing.T) {
+	line := Line([]rune("foo\nbar\nbaz"))
+	marks := NewMarks(&line)
+
+	// Changes on the same line are merged.
+	line.Insert(5, 'x')
+	marks.Update()
+	line.Insert(6, 'y')
+	marks.Update()
+	line.Insert(11, 'z')
+	marks.Update()
+	line.Insert(0, '#')
+	marks.Update()
+
+	want := []int{0, 12, 7}
+	for _, pos := range want {
+		got, found := marks.ChangeOlder()
+		if !found || got != pos {
+			t.Fatalf("Marks.ChangeOlder() = (%d, %t), want (%d, true)", got, found, pos)
+		}
+	}
+
+	if _, found := marks.ChangeOlder(); found {
+		t.Error("Marks.ChangeOlder(): found a change before the first one")
+	}
+
+	if got, _ := marks.ChangeNewer(); got != 12 {
+		t.Errorf("Marks.ChangeNewer() = %d, want %d", got, 12)
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/reeflective/readline/internal/color"
+)
+
+// SetSearch sets the pattern of the last search, whose matches in the line are highlighted
+// (see HighlightSearch) and navigated with NextMatch. A nil pattern clears the highlighting,
+// but keeps whether the last search matched whole words only (see SearchWord).
+func (s *Selection) SetSearch(pattern *regexp.Regexp) {
+	s.search = pattern
+
+	if pattern != nil {
+		s.searchWord = false
+	}
+}
+
+// SetSearchWord sets the last search to a whole word, whose occurrences in the line
+// are only matched when they are not part of a longer word (see Line.SelectWord).
+func (s *Selection) SetSearchWord(word string) {
+	s.search = regexp.MustCompile(regexp.QuoteMeta(word))
+	s.searchWord = true
+}
+
+// SearchWord returns true if the last search matches whole words only (see SetSearchWord).
+func (s *Selection) SearchWord() bool {
+	return s.searchWord
+}
+
+// Search returns the pattern of the last search, or nil if none is set.
+func (s *Selection) Search() *regexp.Regexp {
+	return s.search
+}
+
+// SearchMatches returns the beginning and end (exclusive) positions
+// in the line of all non-empty matches of the last search pattern.
+func (s *Selection) SearchMatches() (matches [][2]int) {
+	if s.search == nil {
+		return nil
+	}
+
+	line := string(*s.line)
+
+	for _, match := range s.search.FindAllStringIndex(line, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+
+		bpos := utf8.RuneCountInString(line[:match[0]])
+		epos := bpos + utf8.RuneCountInString(line[match[0]:match[1]])
+
+		if s.searchWord {
+			if wbpos, wepos := s.line.SelectWord(bpos); wbpos != bpos || wepos != epos-1 {
+				continue
+			}
+		}
+
+		matches = append(matches, [2]int{bpos, epos})
+	}
+
+	return matches
+}
+
+// NextMatch returns the position of the next (or previous if not forward) match of the
+// last search pattern after (or before) pos. If there is none, the search wraps around
+// the line, and wrapped is true. If there are no matches in the line, found is false.
+func (s *Selection) NextMatch(pos int, forward bool) (match int, wrapped, found bool) {
+	matches := s.SearchMatches()
+	if len(matches) == 0 {
+		return pos, false, false
+	}
+
+	if forward {
+		for _, m := range matches {
+			if m[0] > pos {
+				return m[0], false, true
+			}
+		}
+
+		return matches[0][0], true, true
+	}
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i][0] < pos {
+			return matches[i][0], false, true
+		}
+	}
+
+	return matches[len(matches)-1][0], true, true
+}
+
+// HighlightSearch adds highlighting to all matches
+// of the last search pattern in the line, if any.
+func HighlightSearch(sel *Selection) {
+	for _, match := range sel.SearchMatches() {
+		sel.surrounds = append(sel.surrounds, Selection{
+			Type:   "search",
+			active: true,
+			visual: true,
+			bpos:   match[0],
+			epos:   match[1] - 1,
+			fg:     color.FgBlack,
+			bg:     color.BgYellow,
+			line:   sel.line,
+			cursor: sel.cursor,
+		})
+	}
+}
+
+// ResetSearch is used by the display engine
+// to reset search matches highlighting regions.
+func ResetSearch(sel *Selection) {
+	var surrounds []Selection
+
+	for _, surround := range sel.surrounds {
+		if surround.Type == "search" {
+			continue
+		}
+
+		surrounds = append(surrounds, surround)
+	}
+
+	sel.surrounds = surrounds
+}
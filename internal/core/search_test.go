@@ -0,0 +1,78 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestSelection_SearchMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		pattern string
+		word    string
+		want    [][2]int
+	}{
+		{name: "No pattern", line: "foo bar"},
+		{name: "No matches", line: "foo bar", pattern: "baz"},
+		{name: "Matches", line: "foo bar foo", pattern: "foo", want: [][2]int{{0, 3}, {8, 11}}},
+		{name: "Multibyte", line: "é foo été foo", pattern: "foo", want: [][2]int{{2, 5}, {10, 13}}},
+		{name: "Empty matches", line: "foo bar", pattern: "x*"},
+		{name: "Whole words", line: "foo foobar foo", word: "foo", want: [][2]int{{0, 3}, {11, 14}}},
+		{name: "Non-ASCII word", line: "café cafés café", word: "café", want: [][2]int{{0, 4}, {11, 15}}},
+		{name: "Punctuation word", line: "-- --- a--b --", word: "--", want: [][2]int{{0, 2}, {8, 10}, {12, 14}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			sel := NewSelection(&line, NewCursor(&line))
+
+			if test.pattern != "" {
+				sel.SetSearch(regexp.MustCompile(test.pattern))
+			}
+
+			if test.word != "" {
+				sel.SetSearchWord(test.word)
+			}
+
+			if got := sel.SearchMatches(); !slices.Equal(got, test.want) {
+				t.Errorf("Selection.SearchMatches() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelection_NextMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		pos     int
+		forward bool
+		match   int
+		wrapped bool
+		found   bool
+	}{
+		{name: "Forward", line: "foo bar foo", pos: 0, forward: true, match: 8, found: true},
+		{name: "Forward wraps", line: "foo bar foo", pos: 8, forward: true, match: 0, wrapped: true, found: true},
+		{name: "Backward", line: "foo bar foo", pos: 8, match: 0, found: true},
+		{name: "Backward wraps", line: "foo bar foo", pos: 0, match: 8, wrapped: true, found: true},
+		{name: "Single match", line: "bar foo", pos: 4, forward: true, match: 4, wrapped: true, found: true},
+		{name: "Not found", line: "bar baz", pos: 2, forward: true, match: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := Line(test.line)
+			sel := NewSelection(&line, NewCursor(&line))
+			sel.SetSearch(regexp.MustCompile("foo"))
+
+			match, wrapped, found := sel.NextMatch(test.pos, test.forward)
+			if match != test.match || wrapped != test.wrapped || found != test.found {
+				t.Errorf("Selection.NextMatch() = (%d, %t, %t), want (%d, %t, %t)",
+					match, wrapped, found, test.match, test.wrapped, test.found)
+			}
+		})
+	}
+}
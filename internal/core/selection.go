@@ -14,19 +14,30 @@ import (
 // with the default cursor mark and position, and contains a list of additional surround
 // selections used to change/select multiple parts of the line at once.
 type Selection struct {
-	Type       string // Can be a normal one, surrounding (pairs), (cursor) matchers, etc.
-	active     bool   // The selection is running.
-	visual     bool   // The selection is highlighted.
-	visualLine bool   // The selection should span entire lines.
-	bpos       int    // Beginning index position
-	epos       int    // End index position (can be +1 in visual mode, to encompass cursor pos)
-	kpos       int    // Keyword regexp matchers cycling counter.
-	kmpos      int    // Keyword regexp matcher subgroups counter.
+	Type        string // Can be a normal one, surrounding (pairs), (cursor) matchers, etc.
+	active      bool   // The selection is running.
+	visual      bool   // The selection is highlighted.
+	visualLine  bool   // The selection should span entire lines.
+	visualBlock bool   // The selection is a rectangle spanning several lines.
+	blockEnd    bool   // The visual block extends to the end of each line.
+	blockRow    int    // The line on which the visual block was extended to its end.
+	bpos        int    // Beginning index position
+	epos        int    // End index position (can be +1 in visual mode, to encompass cursor pos)
+	kpos        int    // Keyword regexp matchers cycling counter.
+	kmpos       int    // Keyword regexp matcher subgroups counter.
+	matchers    []KeywordMatcher
 
 	// Display
-	fg        string      // Foreground color of the highlighted selection.
-	bg        string      // Background color.
-	surrounds []Selection // Surrounds are usually pairs of characters matching each other (quotes/brackets, etc.)
+	fg         string         // Foreground color of the highlighted selection.
+	bg         string         // Background color.
+	surrounds  []Selection    // Surrounds are usually pairs of characters matching each other (quotes/brackets, etc.)
+	search     *regexp.Regexp // The last search pattern, whose matches are highlighted.
+	searchWord bool           // The last search matches whole words only.
+
+	// Multiple cursors
+	cursors     []int // Additional cursors, at which the edits of the main one are replicated.
+	cursorsLine Line  // The line as it was after the last edit replicated at all cursors.
+	cursorsPos  int   // The main cursor position after the last edit.
 
 	// Core
 	line   *Line
@@ -108,6 +119,8 @@ func (s *Selection) Active() bool {
 func (s *Selection) Visual(line bool) {
 	s.visual = true
 	s.visualLine = line
+	s.visualBlock = false
+	s.blockEnd = false
 }
 
 // IsVisual indicates whether the selection should be highlighted.
@@ -123,6 +136,16 @@ func (s *Selection) Pos() (bpos, epos int) {
 		return -1, -1
 	}
 
+	// A visual block spans from its top-left to its bottom-right corner.
+	if s.IsVisualBlock() {
+		rows := s.Rows()
+		if len(rows) == 0 {
+			return -1, -1
+		}
+
+		return rows[0].bpos, rows[len(rows)-1].epos + 1
+	}
+
 	bpos, epos, valid := s.checkRange(s.bpos, s.epos)
 	if !valid {
 		return bpos, epos
@@ -154,6 +177,10 @@ func (s *Selection) Pos() (bpos, epos int) {
 // Cursor returns what should be the cursor position if the active
 // selection is to be deleted, but also works for yank operations.
 func (s *Selection) Cursor() int {
+	if s.IsVisualBlock() {
+		return s.blockCursor()
+	}
+
 	bpos, epos := s.Pos()
 	if bpos == -1 && epos == -1 {
 		return s.cursor.Pos()
@@ -237,6 +264,10 @@ func (s *Selection) Text() string {
 		return ""
 	}
 
+	if s.IsVisualBlock() {
+		return s.blockText()
+	}
+
 	bpos, epos := s.Pos()
 	if bpos == -1 || epos == -1 {
 		return ""
@@ -261,7 +292,7 @@ func (s *Selection) Pop() (buf string, bpos, epos, cpos int) {
 	}
 
 	cpos = s.Cursor()
-	buf = string((*s.line)[bpos:epos])
+	buf = s.Text()
 
 	return buf, bpos, epos, cpos
 }
@@ -452,8 +483,8 @@ func (s *Selection) SelectAShellWord() (bpos, epos int) {
 // Repeatedly calling this function will cycle through all regex matches,
 // or if a matcher captured multiple subgroups, through each of those groups.
 //
-// Those are, in the order in which they are tried:
-// URI / URL / Domain|IPv4|IPv6 / URL path component / URL parameters.
+// Those are the keyword matchers set with SetKeywordMatchers, or the built-in ones,
+// in the order in which they are tried (see KeywordMatchers).
 //
 // The returned positions are the beginning and end positions of the match
 // on the line (absolute position, not relative to cursor), or if no matcher
@@ -494,15 +525,22 @@ func (s *Selection) ReplaceWith(replacer func(r rune) rune) {
 
 	defer s.Reset()
 
-	bpos, epos := s.Pos()
-	if bpos == -1 || epos == -1 {
-		return
+	regions := []Selection{*s}
+	if s.IsVisualBlock() {
+		regions = s.Rows()
 	}
 
-	for pos := bpos; pos < epos; pos++ {
-		char := (*s.line)[pos]
-		char = replacer(char)
-		(*s.line)[pos] = char
+	for _, region := range regions {
+		bpos, epos := region.Pos()
+		if bpos == -1 || epos == -1 {
+			continue
+		}
+
+		for pos := bpos; pos < epos; pos++ {
+			char := (*s.line)[pos]
+			char = replacer(char)
+			(*s.line)[pos] = char
+		}
 	}
 }
 
@@ -526,6 +564,9 @@ func (s *Selection) Cut() (buf string) {
 			offset++
 		}
 
+	case s.IsVisualBlock():
+		buf = s.blockCut()
+
 	default:
 		bpos, epos := s.Pos()
 		if bpos == -1 || epos == -1 {
@@ -610,9 +651,12 @@ func (s *Selection) Reset() {
 	s.active = false
 	s.visual = false
 	s.visualLine = false
+	s.visualBlock = false
+	s.blockEnd = false
 	s.bpos = -1
 	s.epos = -1
 	s.kpos = 0
+	s.kmpos = 0
 	s.fg = ""
 	s.bg = ""
 
@@ -743,119 +787,6 @@ func (s *Selection) adjustWordSelection(_, under, after bool, bpos int) (int, in
 	return bpos, epos
 }
 
-func (s *Selection) matchKeyword(buf []rune, bbpos int, next bool) (name string, found bool, bpos, epos int) {
-	matchersNames := []string{
-		"URI",
-	}
-
-	matchers := map[string]*regexp.Regexp{
-		"URI": regexp.MustCompile(`https?:\/\/(?:www\.)?([-a-zA-Z0-9@:%._\+~#=]{2,256}\.[a-z]{2,6}\b)*(\/[\/\d\w\.-]*)*(?:[\?])*(.+)*`),
-	}
-
-	// The matcher name and all the capturing subgroups it found.
-	var matcherName string
-	var groups []int
-
-	// Always preload the current/last target matcher used.
-	if s.kpos > 0 && s.kpos <= len(matchersNames) {
-		matcherName = matchersNames[s.kpos-1]
-		matcher := matchers[matcherName]
-
-		groups = s.runMatcher(buf, matcher)
-	}
-
-	// Either increment/decrement the counter (switch the matcher altogether),
-	// or cycle through the match groups for the current matcher.
-	if sbpos, sepos, cycled := s.cycleSubgroup(groups, bbpos, next); cycled {
-		return matcherName, true, sbpos, sepos
-	}
-
-	// We did not cycle through subgroups, so cycle the matchers.
-	prevPos := s.kpos
-	s.kmpos = 1
-
-	if next {
-		s.kpos++
-	} else {
-		s.kpos--
-	}
-
-	if s.kpos > len(matchersNames) {
-		s.kpos = 1
-	}
-
-	if s.kpos < 1 {
-		s.kpos = len(matchersNames)
-	}
-
-	// Prepare the cycling functions, increments and counters.
-	var (
-		kpos = s.kpos
-		done func(i int) bool
-		move func(inc int) int
-	)
-
-	if next {
-		done = func(i int) bool { return i <= len(matchersNames) }
-		move = func(inc int) int { return kpos + 1 }
-	} else {
-		done = func(i int) bool { return i > 0 }
-		move = func(inc int) int { return kpos - 1 }
-	}
-
-	// Try the different matchers until one succeeds, and select the first/last capturing group.
-	for done(kpos) {
-		matcherName = matchersNames[kpos-1]
-		matcher := matchers[matcherName]
-
-		groups = s.runMatcher(buf, matcher)
-
-		if len(groups) <= 1 {
-			kpos = move(kpos)
-			continue
-		}
-
-		if !next && prevPos == 1 {
-			s.kmpos = len(groups)
-
-			return matcherName, true, bbpos + groups[len(groups)-2], bbpos + groups[len(groups)-1]
-		}
-
-		return matcherName, true, bbpos + groups[0], bbpos + groups[1]
-	}
-
-	return "", false, -1, -1
-}
-
-func (s *Selection) cycleSubgroup(groups []int, bbpos int, next bool) (bpos, epos int, cycled bool) {
-	canCycleSubgroup := (next && s.kmpos < len(groups)-1) || (!next && s.kmpos > 1)
-
-	if !canCycleSubgroup {
-		return
-	}
-
-	if next {
-		s.kmpos++
-	} else {
-		s.kmpos--
-	}
-
-	return bbpos + groups[s.kmpos-1], bbpos + groups[s.kmpos], true
-}
-
-func (s *Selection) runMatcher(buf []rune, matcher *regexp.Regexp) (groups []int) {
-	if matcher == nil {
-		return
-	}
-
-	matches := matcher.FindAllStringSubmatchIndex(string(buf), -1)
-	if len(matches) == 0 {
-		return
-	}
-
-	return matches[0]
-}
-
 // Tested URL / IP regexp matchers, not working as well as the current ones
 //
 // "URL": regexp.MustCompile(`([\w+]+\:\/\/)?([\w\d-]+\.)*[\w-]+[\.\:]\w+([\/\?\=\&\#.]?[\w-]+)*\/?`),
@@ -1231,7 +1231,7 @@ func TestSelection_SelectKeyword(t *testing.T) {
 			fields:    fieldsWith(urlLine, &urlCur),
 			args:      args{cpos: 32, bpos: 0, epos: 0, next: true, cycles: 2},
 			wantKbpos: 19,
-			wantKepos: 12 + 57,
+			wantKepos: 34,
 			wantMatch: true,
 		},
 		{
@@ -0,0 +1,284 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// TextObject returns the beginning and end positions (both included) of a text object
+// at (or around) the position pos in the line, like the Vim iw/aw or i(/a( objects.
+// When around is true, the object includes its surroundings (blanks, separators, etc),
+// otherwise only its inner part. If there is no such object, both positions are -1.
+type TextObject func(line []rune, pos int, around bool) (bpos, epos int)
+
+// ArgumentObject is the text object of a function or command argument. Inside brackets,
+// arguments are separated by commas (or by blanks if there are none), and otherwise by
+// blanks. Nested brackets and quotes are skipped. The outer object also includes the
+// separator and blanks after the argument, or before it if it is the last one.
+func ArgumentObject(line []rune, pos int, around bool) (bpos, epos int) {
+	if len(line) == 0 {
+		return -1, -1
+	}
+
+	pos = max(0, min(pos, len(line)-1))
+	start, end := argumentsRange(line, pos)
+
+	var args [][2]int
+	if start > 0 && hasTopLevelComma(line, start, end) {
+		args = splitArguments(line, start, end, func(r rune) bool { return r == ',' })
+	} else {
+		args = splitArguments(line, start, end, unicode.IsSpace)
+	}
+
+	// The argument under the cursor, or the next one if the
+	// cursor is on a separator, or else the last one.
+	current := -1
+
+	for i, arg := range args {
+		if pos <= arg[1] {
+			current = i
+			break
+		}
+	}
+
+	if current == -1 && len(args) > 0 && pos >= start && pos <= end {
+		current = len(args) - 1
+	}
+
+	if current == -1 {
+		return -1, -1
+	}
+
+	bpos, epos = args[current][0], args[current][1]
+
+	switch {
+	case !around:
+	case current < len(args)-1:
+		epos = args[current+1][0] - 1
+	case current > 0:
+		bpos = args[current-1][1] + 1
+	}
+
+	return bpos, epos
+}
+
+// IndentObject is the text object of a block of lines (in a multiline buffer) having
+// the same or a deeper indentation than the line at pos, blank lines included, from the
+// first non-blank character of the block. The outer object also includes the line above
+// the block, which is usually its header (like a function or loop declaration).
+func IndentObject(line []rune, pos int, around bool) (bpos, epos int) {
+	lines := strings.Split(string(line), "\n")
+	offsets := make([]int, len(lines)+1)
+
+	for i, text := range lines {
+		offsets[i+1] = offsets[i] + len([]rune(text)) + 1
+	}
+
+	row := 0
+	for row < len(lines)-1 && offsets[row+1] <= pos {
+		row++
+	}
+
+	// Blank lines belong to the next indented line, or the previous one.
+	ref := row
+	for ref < len(lines)-1 && isBlankLine(lines[ref]) {
+		ref++
+	}
+
+	for ref > 0 && isBlankLine(lines[ref]) {
+		ref--
+	}
+
+	if isBlankLine(lines[ref]) {
+		return -1, -1
+	}
+
+	indent := lineIndent(lines[ref])
+	inBlock := func(row int) bool {
+		return isBlankLine(lines[row]) || lineIndent(lines[row]) >= indent
+	}
+
+	first, last := ref, ref
+
+	for first > 0 && inBlock(first-1) {
+		first--
+	}
+
+	for last < len(lines)-1 && inBlock(last+1) {
+		last++
+	}
+
+	for isBlankLine(lines[first]) {
+		first++
+	}
+
+	for isBlankLine(lines[last]) {
+		last--
+	}
+
+	// The header is the first non-blank line above the block.
+	header := first - 1
+	for header >= 0 && isBlankLine(lines[header]) {
+		header--
+	}
+
+	if around && header >= 0 {
+		first = header
+	}
+
+	bpos = offsets[first] + lineIndent(lines[first])
+	epos = offsets[last] + len([]rune(lines[last])) - 1
+
+	return bpos, epos
+}
+
+// EntireObject is the text object of the entire buffer.
+// The inner object excludes leading and trailing blanks.
+func EntireObject(line []rune, _ int, around bool) (bpos, epos int) {
+	bpos, epos = 0, len(line)-1
+
+	for !around && bpos <= epos && unicode.IsSpace(line[bpos]) {
+		bpos++
+	}
+
+	for !around && epos >= bpos && unicode.IsSpace(line[epos]) {
+		epos--
+	}
+
+	if epos < bpos {
+		return -1, -1
+	}
+
+	return bpos, epos
+}
+
+// argumentsRange returns the range of the innermost brackets enclosing
+// pos, without the brackets themselves, or the entire line if none.
+func argumentsRange(line []rune, pos int) (start, end int) {
+	var opened []int
+
+	enclosing, found := -1, false
+	end = len(line)
+
+	scanArguments(line, 0, len(line), func(i int, char rune, _ int) {
+		// Quoted characters are not scanned, but do not change the brackets either.
+		if !found && i >= pos {
+			enclosing, found = lastOpened(opened), true
+		}
+
+		_, closer := strutil.SurroundType(char)
+
+		switch {
+		case !strutil.IsBracket(char):
+		case !closer:
+			opened = append(opened, i)
+		case len(opened) > 0:
+			if opened[len(opened)-1] == enclosing && end == len(line) {
+				end = i
+			}
+
+			opened = opened[:len(opened)-1]
+		}
+	})
+
+	if !found {
+		enclosing = lastOpened(opened)
+	}
+
+	if enclosing == -1 {
+		return 0, len(line)
+	}
+
+	return enclosing + 1, end
+}
+
+// splitArguments returns the ranges (both included) of the arguments between
+// start and end, separated by top-level separators and trimmed of blanks.
+func splitArguments(line []rune, start, end int, isSep func(rune) bool) (args [][2]int) {
+	argStart := start
+
+	addArg := func(bpos, epos int) {
+		for bpos < epos && unicode.IsSpace(line[bpos]) {
+			bpos++
+		}
+
+		for epos > bpos && unicode.IsSpace(line[epos-1]) {
+			epos--
+		}
+
+		if epos > bpos {
+			args = append(args, [2]int{bpos, epos - 1})
+		}
+	}
+
+	scanArguments(line, start, end, func(i int, char rune, depth int) {
+		if depth == 0 && isSep(char) {
+			addArg(argStart, i)
+			argStart = i + 1
+		}
+	})
+
+	addArg(argStart, end)
+
+	return args
+}
+
+// hasTopLevelComma returns true if there is a comma
+// outside of nested brackets between start and end.
+func hasTopLevelComma(line []rune, start, end int) (found bool) {
+	scanArguments(line, start, end, func(_ int, char rune, depth int) {
+		found = found || (char == ',' && depth == 0)
+	})
+
+	return found
+}
+
+// scanArguments calls fn for each character between start and end which is neither
+// quoted nor escaped, with the depth of the brackets nested around it since start.
+func scanArguments(line []rune, start, end int, fn func(i int, char rune, depth int)) {
+	var quote rune
+
+	depth := 0
+
+	for i := start; i < end; i++ {
+		char := line[i]
+
+		switch {
+		case char == '\\' && quote != '\'':
+			i++
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+		case char == '\'' || char == '"':
+			quote = char
+		case strutil.IsBracket(char):
+			if _, closer := strutil.SurroundType(char); closer {
+				depth = max(0, depth-1)
+				fn(i, char, depth)
+			} else {
+				fn(i, char, depth)
+				depth++
+			}
+		default:
+			fn(i, char, depth)
+		}
+	}
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func lineIndent(line string) int {
+	return len([]rune(line)) - len([]rune(strings.TrimLeft(line, " \t")))
+}
+
+func lastOpened(positions []int) int {
+	if len(positions) == 0 {
+		return -1
+	}
+
+	return positions[len(positions)-1]
+}
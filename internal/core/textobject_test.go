@@ -0,0 +1,129 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// objectText returns the text selected by a text object at the position
+// of the first | in the line (which is removed), or "<none>" if none.
+func objectText(object TextObject, line string, around bool) string {
+	pos := strings.Index(line, "|")
+	runes := []rune(strings.Replace(line, "|", "", 1))
+	pos = len([]rune(line[:pos]))
+
+	bpos, epos := object(runes, pos, around)
+	if bpos == -1 || epos == -1 {
+		return "<none>"
+	}
+
+	return string(runes[bpos : epos+1])
+}
+
+func TestArgumentObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		inner  string
+		around string
+	}{
+		{name: "First argument", line: "foo(a|bc, def)", inner: "abc", around: "abc, "},
+		{name: "Last argument", line: "foo(abc, d|ef)", inner: "def", around: ", def"},
+		{name: "Middle argument", line: "f(a,  |b , c)", inner: "b", around: "b , "},
+		{name: "On separator", line: "f(a|, b)", inner: "b", around: ", b"},
+		{name: "Nested brackets", line: "f(a, g(b, |c), d)", inner: "c", around: ", c"},
+		{name: "Around nested call", line: "f(a, g|(b, c), d)", inner: "g(b, c)", around: "g(b, c), "},
+		{name: "Quoted comma", line: `f("a, |b", c)`, inner: `"a, b"`, around: `"a, b", `},
+		{name: "Blank separated", line: "(list a|b c)", inner: "ab", around: "ab "},
+		{name: "Command arguments", line: "git commit -m|sg 'a b'", inner: "-msg", around: "-msg "},
+		{name: "Last command argument", line: "git commit -m 'a |b'", inner: "'a b'", around: " 'a b'"},
+		{name: "Closing bracket", line: "f(a, b|)", inner: "b", around: ", b"},
+		{name: "Empty brackets", line: "f(|)", inner: "<none>", around: "<none>"},
+		{name: "Empty line", line: "|", inner: "<none>", around: "<none>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := objectText(ArgumentObject, test.line, false); got != test.inner {
+				t.Errorf("ArgumentObject() inner = %q, want %q", got, test.inner)
+			}
+
+			if got := objectText(ArgumentObject, test.line, true); got != test.around {
+				t.Errorf("ArgumentObject() around = %q, want %q", got, test.around)
+			}
+		})
+	}
+}
+
+func TestIndentObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		inner  string
+		around string
+	}{
+		{
+			name:   "Block",
+			line:   "for i in *; do\n  echo |$i\n\n  ls\ndone",
+			inner:  "echo $i\n\n  ls",
+			around: "for i in *; do\n  echo $i\n\n  ls",
+		},
+		{
+			name:   "Nested block",
+			line:   "if a; then\n  if b; then\n    |c\n  fi\nfi",
+			inner:  "c",
+			around: "if b; then\n    c",
+		},
+		{
+			name:   "Outer block",
+			line:   "if a; then\n  if b; then\n    c\n  f|i\nfi",
+			inner:  "if b; then\n    c\n  fi",
+			around: "if a; then\n  if b; then\n    c\n  fi",
+		},
+		{
+			name:   "Blank line",
+			line:   "f {\n|\n  a\n}",
+			inner:  "a",
+			around: "f {\n\n  a",
+		},
+		{name: "Single line", line: "ec|ho", inner: "echo", around: "echo"},
+		{name: "Blank buffer", line: " | \n", inner: "<none>", around: "<none>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := objectText(IndentObject, test.line, false); got != test.inner {
+				t.Errorf("IndentObject() inner = %q, want %q", got, test.inner)
+			}
+
+			if got := objectText(IndentObject, test.line, true); got != test.around {
+				t.Errorf("IndentObject() around = %q, want %q", got, test.around)
+			}
+		})
+	}
+}
+
+func TestEntireObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		inner  string
+		around string
+	}{
+		{name: "Buffer", line: "\n  echo |a\nb \n", inner: "echo a\nb", around: "\n  echo a\nb \n"},
+		{name: "Blank buffer", line: " | ", inner: "<none>", around: "  "},
+		{name: "Empty buffer", line: "|", inner: "<none>", around: "<none>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := objectText(EntireObject, test.line, false); got != test.inner {
+				t.Errorf("EntireObject() inner = %q, want %q", got, test.inner)
+			}
+
+			if got := objectText(EntireObject, test.line, true); got != test.around {
+				t.Errorf("EntireObject() around = %q, want %q", got, test.around)
+			}
+		})
+	}
+}
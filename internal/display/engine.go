@@ -23,6 +23,7 @@ var (
 type Engine struct {
 	// Operating parameters
 	highlighter    func(line []rune) string
+	spans          func(line []rune) []Span
 	startCols      int
 	startRows      int
 	lineCol        int
@@ -60,10 +61,12 @@ func NewEngine(k *core.Keys, s *core.Selection, h *history.Sources, p *ui.Prompt
 }
 
 // Init computes some base coordinates needed before displaying the line and helpers.
-// The shell syntax highlighter is also provided here, since any consumer library will
-// have bound it after instantiating a new shell instance.
-func Init(e *Engine, highlighter func([]rune) string) {
+// The shell syntax highlighters are also provided here, since any consumer library will
+// have bound them after instantiating a new shell instance. If both are non-nil, the
+// span highlighter is used.
+func Init(e *Engine, highlighter func([]rune) string, spans func([]rune) []Span) {
 	e.highlighter = highlighter
+	e.spans = spans
 }
 
 // Refresh recomputes and redisplays the entire readline interface, except
@@ -235,21 +238,33 @@ func (e *Engine) computeCoordinates(suggested bool) {
 func (e *Engine) displayLine() {
 	var line string
 
-	// Apply user-defined highlighter to the input line.
-	if e.highlighter != nil {
-		line = e.highlighter(*e.line)
-	} else {
-		line = string(*e.line)
-	}
-
 	// Highlight matching parenthesis
 	if e.opts.GetBool("blink-matching-paren") {
 		core.HighlightMatchers(e.selection)
 		defer core.ResetMatchers(e.selection)
 	}
 
-	// Apply visual selections highlighting if any
-	line = e.highlightLine([]rune(line), *e.selection)
+	// Highlight all matches of the last search
+	core.HighlightSearch(e.selection)
+	defer core.ResetSearch(e.selection)
+
+	// Highlight all additional cursors
+	core.HighlightCursors(e.selection)
+	defer core.ResetCursorsHighlight(e.selection)
+
+	switch {
+	case e.spans != nil:
+		// Merge user-defined styled spans with visual selections.
+		line = e.highlightSpans(*e.line, e.spans(*e.line), *e.selection)
+
+	case e.highlighter != nil:
+		// Apply user-defined highlighter to the input line,
+		// and then visual selections highlighting if any.
+		line = e.highlightLine([]rune(e.highlighter(*e.line)), *e.selection)
+
+	default:
+		line = e.highlightLine(*e.line, *e.selection)
+	}
 
 	// Get the subset of the suggested line to print.
 	if len(e.suggested) > e.line.Len() && e.opts.GetBool("history-autosuggest") {
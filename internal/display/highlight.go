@@ -6,11 +6,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/reeflective/readline/internal/color"
 	"github.com/reeflective/readline/internal/core"
 )
 
+var commentColor = color.SGRStart + color.Fg + "244" + color.SGREnd
+
 // highlightLine applies visual/selection highlighting to a line.
 // The provided line might already have been highlighted by a user-provided
 // highlighter: this function accounts for any embedded color sequences.
@@ -31,11 +34,7 @@ func (e *Engine) highlightLine(line []rune, selection core.Selection) string {
 	}
 
 	// Finally, highlight comments using a regex.
-	comment := strings.Trim(e.opts.GetString("comment-begin"), "\"")
-	commentPattern := fmt.Sprintf(`(^|\s)%s.*`, comment)
-
-	if commentsMatch, err := regexp.Compile(commentPattern); err == nil {
-		commentColor := color.SGRStart + color.Fg + "244" + color.SGREnd
+	if commentsMatch := e.commentMatcher(); commentsMatch != nil {
 		highlighted = commentsMatch.ReplaceAllString(highlighted, fmt.Sprintf("%s${0}%s", commentColor, color.Reset))
 	}
 
@@ -44,6 +43,91 @@ func (e *Engine) highlightLine(line []rune, selection core.Selection) string {
 	return highlighted
 }
 
+// Span is a range of runes [Start, End) of the input line, highlighted with
+// a style (one or more SGR sequences). Spans can overlap: the last one wins.
+type Span struct {
+	Start int
+	End   int
+	Style string
+}
+
+// highlightSpans renders a plain line with styled spans, merged with visual
+// selections, surrounds and matchers. Since spans are not embedded in the line,
+// the style of each rune is known, and is fully applied (after a reset) each
+// time it changes: selections are always applied on top of the span styles.
+func (e *Engine) highlightSpans(line []rune, spans []Span, selection core.Selection) string {
+	styles := make([]string, len(line))
+
+	// Comments are highlighted first, so that users can override them.
+	if commentsMatch := e.commentMatcher(); commentsMatch != nil {
+		for _, match := range commentsMatch.FindAllStringIndex(string(line), -1) {
+			start := utf8.RuneCountInString(string(line)[:match[0]])
+			end := start + utf8.RuneCountInString(string(line)[match[0]:match[1]])
+			spans = append([]Span{{Start: start, End: end, Style: commentColor}}, spans...)
+		}
+	}
+
+	for _, span := range spans {
+		for pos := max(span.Start, 0); pos < min(span.End, len(line)); pos++ {
+			styles[pos] = span.Style
+		}
+	}
+
+	sorted := sortHighlights(selection)
+
+	var (
+		highlighted strings.Builder
+		current     string
+	)
+
+	for pos, char := range line {
+		style := styles[pos] + e.regionAt(sorted, pos)
+
+		if style != current {
+			highlighted.WriteString(color.Reset + style)
+			current = style
+		}
+
+		highlighted.WriteRune(char)
+	}
+
+	highlighted.WriteString(color.Reset)
+
+	return highlighted.String()
+}
+
+// regionAt returns the highlighting of the last
+// sorted region containing the position, if any.
+func (e *Engine) regionAt(sorted []core.Selection, pos int) string {
+	var regions []core.Selection
+
+	for _, reg := range sorted {
+		if bpos, epos := reg.Pos(); bpos <= pos && pos < epos {
+			regions = append(regions, reg)
+		}
+	}
+
+	if len(regions) == 0 {
+		return ""
+	}
+
+	return string(e.hlAdd(regions, core.Selection{}, nil))
+}
+
+// commentMatcher returns a regular expression matching
+// comments, as started with the comment-begin option.
+func (e *Engine) commentMatcher() *regexp.Regexp {
+	comment := strings.Trim(e.opts.GetString("comment-begin"), "\"")
+	commentPattern := fmt.Sprintf(`(^|\s)%s.*`, comment)
+
+	commentsMatch, err := regexp.Compile(commentPattern)
+	if err != nil {
+		return nil
+	}
+
+	return commentsMatch
+}
+
 func sortHighlights(vhl core.Selection) []core.Selection {
 	all := make([]core.Selection, 0)
 	sorted := make([]core.Selection, 0)
@@ -55,10 +139,18 @@ func sortHighlights(vhl core.Selection) []core.Selection {
 		bpos = append(bpos, rbpos)
 	}
 
-	if vhl.Active() && vhl.IsVisual() {
-		all = append(all, vhl)
-		vbpos, _ := vhl.Pos()
-		bpos = append(bpos, vbpos)
+	// Visual blocks are highlighted line by line.
+	visuals := []core.Selection{vhl}
+	if vhl.IsVisualBlock() {
+		visuals = vhl.Rows()
+	}
+
+	for _, visual := range visuals {
+		if visual.Active() && visual.IsVisual() {
+			all = append(all, visual)
+			vbpos, _ := visual.Pos()
+			bpos = append(bpos, vbpos)
+		}
 	}
 
 	sort.Ints(bpos)
@@ -158,9 +250,9 @@ func (e *Engine) getHighlights(line []rune, sorted []core.Selection) map[int][]r
 
 func (e *Engine) hlAdd(regions []core.Selection, newHl core.Selection, line []rune) []rune {
 	var (
-		fg, bg  string
-		matcher bool
-		hl      core.Selection
+		fg, bg    string
+		ownColors bool
+		hl        core.Selection
 	)
 
 	if newHl.Active() {
@@ -170,10 +262,10 @@ func (e *Engine) hlAdd(regions []core.Selection, newHl core.Selection, line []ru
 	}
 
 	fg, bg = hl.Highlights()
-	matcher = hl.Type == "matcher"
+	ownColors = hl.Type == "matcher" || hl.Type == "search" || hl.Type == "cursor"
 
 	// Update the highlighting with inputrc settings if any.
-	if bg != "" && !matcher {
+	if bg != "" && !ownColors {
 		background := color.UnquoteRC("active-region-start-color")
 		if bg, _ = strconv.Unquote(background); bg == "" {
 			bg = color.Reverse
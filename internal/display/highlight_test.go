@@ -0,0 +1,91 @@
+package display
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/core"
+)
+
+func TestEngine_highlightSpans(t *testing.T) {
+	const (
+		red  = "\x1b[31m"
+		blue = "\x1b[34m"
+	)
+
+	line := core.Line("echo foo # bar")
+	cursor := core.NewCursor(&line)
+	selection := core.NewSelection(&line, cursor)
+
+	eng := NewEngine(nil, selection, nil, nil, nil, nil, inputrc.NewDefaultConfig())
+	spans := []Span{{Start: 0, End: 4, Style: red}, {Start: 5, End: 8, Style: blue}}
+
+	// Without selection, spans and comments are styled in place.
+	want := color.Reset + red + "echo" + color.Reset + " " + color.Reset + blue + "foo" +
+		color.Reset + commentColor + " # bar" + color.Reset
+	if got := eng.highlightSpans(line, spans, *selection); got != want {
+		t.Errorf("Engine.highlightSpans() = %q, want %q", got, want)
+	}
+
+	// A selection over a span keeps the span style, with the selection on top
+	// (visual selections include the character under their end position).
+	selection.MarkRange(2, 6)
+	selection.Visual(false)
+
+	region := string(eng.hlAdd([]core.Selection{*selection}, core.Selection{}, nil))
+	want = color.Reset + red + "ec" + color.Reset + red + region + "ho" + color.Reset + region + " " +
+		color.Reset + blue + region + "fo" + color.Reset + blue + "o" +
+		color.Reset + commentColor + " # bar" + color.Reset
+
+	if got := eng.highlightSpans(line, spans, *selection); got != want {
+		t.Errorf("Engine.highlightSpans() = %q, want %q", got, want)
+	}
+}
+
+func TestEngine_highlightSpansBlock(t *testing.T) {
+	line := core.Line("abc\nd\nefg")
+	cursor := core.NewCursor(&line)
+	selection := core.NewSelection(&line, cursor)
+
+	eng := NewEngine(nil, selection, nil, nil, nil, nil, inputrc.NewDefaultConfig())
+
+	// A block from the second column of the first line to the
+	// second column of the last one, skipping the short line.
+	selection.Mark(1)
+	selection.VisualBlock()
+	cursor.Set(8)
+
+	region := string(eng.hlAdd([]core.Selection{*selection}, core.Selection{}, nil))
+	want := "a" + color.Reset + region + "bc" + color.Reset + "\nd\ne" + color.Reset + region + "fg" + color.Reset
+
+	if got := eng.highlightSpans(line, nil, *selection); got != want {
+		t.Errorf("Engine.highlightSpans() = %q, want %q", got, want)
+	}
+}
+
+func TestEngine_highlightSpansSearch(t *testing.T) {
+	line := core.Line("foo bar foo")
+	cursor := core.NewCursor(&line)
+	selection := core.NewSelection(&line, cursor)
+
+	eng := NewEngine(nil, selection, nil, nil, nil, nil, inputrc.NewDefaultConfig())
+
+	selection.SetSearch(regexp.MustCompile("foo"))
+	core.HighlightSearch(selection)
+
+	// Matches keep their own colors, instead of the active region ones.
+	match := color.Reset + color.BgYellow + color.FgBlack
+	want := match + "foo" + color.Reset + " bar " + match + "foo" + color.Reset
+
+	if got := eng.highlightSpans(line, nil, *selection); got != want {
+		t.Errorf("Engine.highlightSpans() = %q, want %q", got, want)
+	}
+
+	core.ResetSearch(selection)
+
+	if got := eng.highlightSpans(line, nil, *selection); got != "foo bar foo"+color.Reset {
+		t.Errorf("Engine.highlightSpans() after ResetSearch() = %q", got)
+	}
+}
@@ -0,0 +1,459 @@
+package editor
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Errors returned by ex command lines.
+var (
+	ErrExCommand   = errors.New("not an editor command")
+	ErrExRange     = errors.New("invalid range")
+	ErrExNoRange   = errors.New("no range allowed")
+	ErrExArgument  = errors.New("argument required")
+	ErrExTrailing  = errors.New("trailing characters")
+	ErrExPattern   = errors.New("pattern not found")
+	ErrExNoPattern = errors.New("no previous regular expression")
+	ErrExOption    = errors.New("unknown option")
+)
+
+// exCommands are the supported ex commands, with the
+// length of the shortest abbreviation of their name.
+var exCommands = []struct {
+	name   string
+	abbrev int
+}{
+	{"display", 2},
+	{"marks", 5},
+	{"nohlsearch", 3},
+	{"normal", 4},
+	{"registers", 3},
+	{"set", 2},
+	{"substitute", 1},
+}
+
+// ExCommand is a parsed ex command line.
+type ExCommand struct {
+	Name   string // Full name of the command.
+	Args   string // Arguments, without leading blanks.
+	Start  int    // First line of the range (from 0).
+	End    int    // Last line of the range (inclusive).
+	Ranged bool   // Whether a range was given.
+}
+
+// Ex keeps the state of the Vim ex command line: its history,
+// the last substitution, and the current command completion.
+type Ex struct {
+	history []string
+	pos     int
+	typed   string
+
+	pattern string
+	replace string
+
+	completed string
+	comps     []string
+	comp      int
+}
+
+// ParseEx parses an ex command line: an optional range, followed by a command name,
+// possibly abbreviated, and its arguments. Lines of the range can be given as numbers
+// (starting from 1), . for the current line, $ for the last one, each optionally
+// followed by +n/-n offsets, or % for all lines. The range defaults to the current line.
+func ParseEx(cmdline string, current, last int) (cmd ExCommand, err error) {
+	cmd.Start, cmd.End = current, current
+	cmdline = strings.TrimLeft(cmdline, ": \t")
+
+	// Range
+	if strings.HasPrefix(cmdline, "%") {
+		cmd.Start, cmd.End, cmd.Ranged = 0, last, true
+		cmdline = cmdline[1:]
+	} else if line, rest, found := parseExAddress(cmdline, current, last); found {
+		cmd.Start, cmd.End, cmd.Ranged = line, line, true
+		cmdline = rest
+
+		if strings.HasPrefix(cmdline, ",") {
+			if cmd.End, cmdline, found = parseExAddress(cmdline[1:], current, last); !found {
+				return cmd, ErrExRange
+			}
+		}
+	}
+
+	if cmd.Start > cmd.End {
+		cmd.Start, cmd.End = cmd.End, cmd.Start
+	}
+
+	if cmd.Start < 0 || cmd.End > last {
+		return cmd, ErrExRange
+	}
+
+	// Command name and arguments
+	name := strings.TrimLeftFunc(cmdline, unicode.IsSpace)
+	end := strings.IndexFunc(name, func(r rune) bool { return !unicode.IsLetter(r) })
+
+	if end == -1 {
+		end = len(name)
+	}
+
+	cmd.Args = strings.TrimLeftFunc(name[end:], unicode.IsSpace)
+	name = name[:end]
+
+	for _, command := range exCommands {
+		if len(name) >= command.abbrev && strings.HasPrefix(command.name, name) {
+			cmd.Name = command.name
+			return cmd, nil
+		}
+	}
+
+	return cmd, ErrExCommand
+}
+
+// parseExAddress parses a line address at the beginning of the command line,
+// and returns the line (from 0) and the rest of the command line, if found.
+func parseExAddress(cmdline string, current, last int) (line int, rest string, found bool) {
+	line = current
+
+	switch {
+	case strings.HasPrefix(cmdline, "."):
+		cmdline, found = cmdline[1:], true
+	case strings.HasPrefix(cmdline, "$"):
+		line, cmdline, found = last, cmdline[1:], true
+	default:
+		digits := exDigits(cmdline)
+		if digits > 0 {
+			num, _ := strconv.Atoi(cmdline[:digits])
+			line, cmdline, found = num-1, cmdline[digits:], true
+		}
+	}
+
+	// Offsets
+	for len(cmdline) > 0 && (cmdline[0] == '+' || cmdline[0] == '-') {
+		sign := 1
+		if cmdline[0] == '-' {
+			sign = -1
+		}
+
+		offset, digits := 1, exDigits(cmdline[1:])
+		if digits > 0 {
+			offset, _ = strconv.Atoi(cmdline[1 : digits+1])
+		}
+
+		line += sign * offset
+		cmdline, found = cmdline[digits+1:], true
+	}
+
+	return line, cmdline, found
+}
+
+func exDigits(cmdline string) (digits int) {
+	for digits < len(cmdline) && cmdline[digits] >= '0' && cmdline[digits] <= '9' {
+		digits++
+	}
+
+	return digits
+}
+
+// Substitute runs a :s/pattern/replacement/flags command on the lines start to end of text.
+// The pattern is a Go regular expression, matched against each line separately: if empty,
+// the last pattern is used. In the replacement, & and \0 stand for the match, \1 to \9 for
+// its groups and \n for a newline. The g flag replaces all matches in each line instead of
+// the first one, and i ignores case. Without arguments, the last substitution is repeated.
+// It returns the new text, and the position of the first non-blank character of the
+// last line in which a substitution occurred.
+func (ex *Ex) Substitute(text string, start, end int, args string) (string, int, error) {
+	pattern, replace, flags := ex.pattern, ex.replace, ""
+
+	if args != "" {
+		delim, size := []rune(args)[0], len(string([]rune(args)[0]))
+		if unicode.IsLetter(delim) || unicode.IsDigit(delim) || unicode.IsSpace(delim) ||
+			strings.ContainsRune(`\"|`, delim) {
+			return text, 0, ErrExTrailing
+		}
+
+		fields := splitExDelim(args[size:], delim)
+		pattern, replace = fields[0], ""
+
+		if len(fields) > 1 {
+			replace = fields[1]
+		}
+
+		if len(fields) > 2 {
+			flags = fields[2]
+		}
+
+		if pattern == "" {
+			pattern = ex.pattern
+		}
+	}
+
+	if pattern == "" {
+		return text, 0, ErrExNoPattern
+	}
+
+	global, regexStr := false, pattern
+
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			global = true
+		case 'i':
+			regexStr = "(?i)" + pattern
+		case 'I':
+		default:
+			return text, 0, ErrExTrailing
+		}
+	}
+
+	regex, err := regexp.Compile(regexStr)
+	if err != nil {
+		return text, 0, err
+	}
+
+	ex.pattern, ex.replace = pattern, replace
+
+	return substituteLines(text, start, end, regex, exTemplate(replace), global)
+}
+
+// substituteLines replaces the matches of regex in the lines start to end of text.
+func substituteLines(text string, start, end int, regex *regexp.Regexp, template string, global bool) (string, int, error) {
+	lines := strings.Split(text, "\n")
+	count := 1
+
+	if global {
+		count = -1
+	}
+
+	lastLine := -1
+
+	for row := start; row <= end && row < len(lines); row++ {
+		line := lines[row]
+
+		matches := regex.FindAllStringSubmatchIndex(line, count)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var buf []byte
+
+		last := 0
+
+		for _, match := range matches {
+			buf = append(buf, line[last:match[0]]...)
+			buf = regex.ExpandString(buf, template, line, match)
+			last = match[1]
+		}
+
+		lines[row] = string(append(buf, line[last:]...))
+		lastLine = row
+	}
+
+	if lastLine == -1 {
+		return text, 0, ErrExPattern
+	}
+
+	// The last line changed might now span several lines.
+	pos := len([]rune(strings.Join(lines[:lastLine], "\n")))
+	if lastLine > 0 {
+		pos++
+	}
+
+	for _, char := range lines[lastLine] {
+		if char != ' ' && char != '\t' {
+			break
+		}
+
+		pos++
+	}
+
+	return strings.Join(lines, "\n"), pos, nil
+}
+
+// splitExDelim splits the arguments of a substitution on unescaped delimiters.
+// Escaped delimiters are unescaped, unless they are special in regular expressions.
+func splitExDelim(args string, delim rune) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		escaped bool
+	)
+
+	special := regexp.QuoteMeta(string(delim)) != string(delim)
+
+	for _, char := range args {
+		switch {
+		case escaped && char == delim && !special:
+			field.WriteRune(char)
+		case escaped:
+			field.WriteRune('\\')
+			field.WriteRune(char)
+		case char == '\\':
+			escaped = true
+			continue
+		case char == delim && len(fields) < 2:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(char)
+		}
+
+		escaped = false
+	}
+
+	if escaped {
+		field.WriteRune('\\')
+	}
+
+	return append(fields, field.String())
+}
+
+// exTemplate converts a Vim substitution replacement to a regexp expansion template.
+func exTemplate(replace string) string {
+	var (
+		template strings.Builder
+		escaped  bool
+	)
+
+	for _, char := range replace {
+		switch {
+		case escaped && char >= '0' && char <= '9':
+			template.WriteString("${" + string(char) + "}")
+		case escaped && char == 'n':
+			template.WriteRune('\n')
+		case escaped && char == '$':
+			template.WriteString("$$")
+		case escaped:
+			template.WriteRune(char)
+		case char == '\\':
+			escaped = true
+			continue
+		case char == '&':
+			template.WriteString("${0}")
+		case char == '$':
+			template.WriteString("$$")
+		default:
+			template.WriteRune(char)
+		}
+
+		escaped = false
+	}
+
+	return template.String()
+}
+
+// Add adds a command line to the ex history, unless it
+// is identical to the last one, and resets the history walk.
+func (ex *Ex) Add(cmdline string) {
+	if cmdline != "" && (len(ex.history) == 0 || ex.history[len(ex.history)-1] != cmdline) {
+		ex.history = append(ex.history, cmdline)
+	}
+
+	ex.ResetWalk()
+}
+
+// ResetWalk goes back to the end of the ex history.
+func (ex *Ex) ResetWalk() {
+	ex.pos = len(ex.history)
+	ex.typed = ""
+}
+
+// Walk returns the previous (or next, if forward) command line in the ex history
+// starting with the command line typed before walking the history. Walking past
+// the most recent command line returns the typed one. If there is no such command
+// line, the current one is returned.
+func (ex *Ex) Walk(cmdline string, forward bool) string {
+	if ex.pos == len(ex.history) {
+		ex.typed = cmdline
+	}
+
+	for pos := ex.pos; ; {
+		if forward {
+			pos++
+		} else {
+			pos--
+		}
+
+		switch {
+		case pos < 0:
+			return cmdline
+		case pos >= len(ex.history):
+			ex.pos = len(ex.history)
+			return ex.typed
+		case strings.HasPrefix(ex.history[pos], ex.typed):
+			ex.pos = pos
+			return ex.history[pos]
+		}
+	}
+}
+
+// Complete completes the command name being typed in the command line, or the
+// variable name being typed as an argument of :set, and returns the completed
+// command line. Completing a command line just completed cycles through candidates.
+func (ex *Ex) Complete(cmdline string, vars []string) string {
+	if cmdline != ex.completed || len(ex.comps) == 0 {
+		ex.comps = completeEx(cmdline, vars)
+		ex.comp = -1
+	}
+
+	if len(ex.comps) == 0 {
+		return cmdline
+	}
+
+	ex.comp = (ex.comp + 1) % len(ex.comps)
+	ex.completed = ex.comps[ex.comp]
+
+	return ex.completed
+}
+
+// completeEx returns all the completed command lines for the word being typed.
+func completeEx(cmdline string, vars []string) (comps []string) {
+	// Skip the range.
+	name := strings.TrimLeftFunc(cmdline, func(r rune) bool {
+		return strings.ContainsRune(":%.$,+- \t", r) || unicode.IsDigit(r)
+	})
+	head := cmdline[:len(cmdline)-len(name)]
+
+	// Command names
+	if !strings.ContainsFunc(name, unicode.IsSpace) {
+		for _, command := range exCommands {
+			if strings.HasPrefix(command.name, name) {
+				comps = append(comps, head+command.name)
+			}
+		}
+
+		return comps
+	}
+
+	// Variables of :set, with an optional "no" or "inv" prefix.
+	if cmd, err := ParseEx(name, 0, 0); err != nil || cmd.Name != "set" {
+		return nil
+	}
+
+	word := name[strings.LastIndexFunc(name, unicode.IsSpace)+1:]
+	head = cmdline[:len(cmdline)-len(word)]
+
+	if strings.Contains(word, "=") {
+		return nil
+	}
+
+	var prefix string
+
+	for _, negate := range []string{"no", "inv"} {
+		if strings.HasPrefix(word, negate) {
+			prefix = negate
+		}
+	}
+
+	for _, name := range vars {
+		switch {
+		case strings.HasPrefix(name, word):
+			comps = append(comps, head+name)
+		case prefix != "" && strings.HasPrefix(name, word[len(prefix):]):
+			comps = append(comps, head+prefix+name)
+		}
+	}
+
+	return comps
+}
@@ -0,0 +1,172 @@
+package editor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseEx(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdline string
+		want    ExCommand
+		err     error
+	}{
+		{name: "No range", cmdline: "s/a/b/", want: ExCommand{Name: "substitute", Args: "/a/b/", Start: 1, End: 1}},
+		{name: "Whole buffer", cmdline: "%s/a/b/g", want: ExCommand{Name: "substitute", Args: "/a/b/g", End: 3, Ranged: true}},
+		{name: "Line numbers", cmdline: "2,3s/a/b/", want: ExCommand{Name: "substitute", Args: "/a/b/", Start: 1, End: 2, Ranged: true}},
+		{name: "Current to last", cmdline: ".,$s", want: ExCommand{Name: "substitute", Start: 1, End: 3, Ranged: true}},
+		{name: "Offsets", cmdline: ".-1,.+2s", want: ExCommand{Name: "substitute", Start: 0, End: 3, Ranged: true}},
+		{name: "Backwards range", cmdline: "3,1s", want: ExCommand{Name: "substitute", Start: 0, End: 2, Ranged: true}},
+		{name: "Abbreviation", cmdline: ":norm dw", want: ExCommand{Name: "normal", Args: "dw", Start: 1, End: 1}},
+		{name: "Shortest name", cmdline: "noh", want: ExCommand{Name: "nohlsearch", Start: 1, End: 1}},
+		{name: "Full name", cmdline: "registers", want: ExCommand{Name: "registers", Start: 1, End: 1}},
+		{name: "Set", cmdline: "se  bell-style=none", want: ExCommand{Name: "set", Args: "bell-style=none", Start: 1, End: 1}},
+		{name: "Too short", cmdline: "re", err: ErrExCommand},
+		{name: "Unknown", cmdline: "quit", err: ErrExCommand},
+		{name: "Out of range", cmdline: "1,5s", err: ErrExRange},
+		{name: "Missing address", cmdline: "1,s", err: ErrExRange},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseEx(test.cmdline, 1, 3)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ParseEx() error = %v, want %v", err, test.err)
+			}
+
+			if test.err == nil && got != test.want {
+				t.Errorf("ParseEx() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEx_Substitute(t *testing.T) {
+	text := "foo bar foo\n  Foo baz\nbar foo"
+
+	tests := []struct {
+		name       string
+		start, end int
+		args       string
+		want       string
+		pos        int
+		err        error
+	}{
+		{name: "First match", end: 0, args: "/foo/qux/", want: "qux bar foo\n  Foo baz\nbar foo", pos: 0},
+		{name: "Global", end: 2, args: "/foo/qux/g", want: "qux bar qux\n  Foo baz\nbar qux", pos: 22},
+		{name: "Ignore case", start: 1, end: 1, args: "/foo/qux/i", want: "foo bar foo\n  qux baz\nbar foo", pos: 14},
+		{name: "Match and groups", end: 0, args: `/(\w+) (\w+)/\2 & \1/`, want: "bar foo bar foo foo\n  Foo baz\nbar foo", pos: 0},
+		{name: "Other delimiter", end: 0, args: "#foo#a/b#", want: "a/b bar foo\n  Foo baz\nbar foo", pos: 0},
+		{name: "Escaped delimiter", end: 0, args: `/foo/a\/b/`, want: "a/b bar foo\n  Foo baz\nbar foo", pos: 0},
+		{name: "Newline", start: 2, end: 2, args: `/ /\n/`, want: "foo bar foo\n  Foo baz\nbar\nfoo", pos: 22},
+		{name: "Delete", end: 0, args: "/ bar", want: "foo foo\n  Foo baz\nbar foo", pos: 0},
+		{name: "Not found", end: 2, args: "/qux/a/", want: text, err: ErrExPattern},
+		{name: "Bad flag", end: 2, args: "/foo/a/x", want: text, err: ErrExTrailing},
+		{name: "Bad delimiter", end: 2, args: "afooabara", want: text, err: ErrExTrailing},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ex := new(Ex)
+
+			got, pos, err := ex.Substitute(text, test.start, test.end, test.args)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Ex.Substitute() error = %v, want %v", err, test.err)
+			}
+
+			if got != test.want {
+				t.Errorf("Ex.Substitute() = %q, want %q", got, test.want)
+			}
+
+			if pos != test.pos {
+				t.Errorf("Ex.Substitute() pos = %d, want %d", pos, test.pos)
+			}
+		})
+	}
+}
+
+func TestEx_SubstituteRepeat(t *testing.T) {
+	ex := new(Ex)
+
+	if _, _, err := ex.Substitute("a", 0, 0, ""); !errors.Is(err, ErrExNoPattern) {
+		t.Fatalf("Ex.Substitute() error = %v, want %v", err, ErrExNoPattern)
+	}
+
+	text, _, _ := ex.Substitute("a a", 0, 0, "/a/b/")
+
+	// Without arguments, the last substitution is repeated,
+	// and an empty pattern is the last one.
+	if text, _, _ = ex.Substitute(text, 0, 0, ""); text != "b b" {
+		t.Errorf("Ex.Substitute() = %q, want %q", text, "b b")
+	}
+
+	if text, _, _ = ex.Substitute("xa", 0, 0, "//c/"); text != "xc" {
+		t.Errorf("Ex.Substitute() = %q, want %q", text, "xc")
+	}
+}
+
+func TestEx_Walk(t *testing.T) {
+	ex := new(Ex)
+	ex.Add("s/a/b/")
+	ex.Add("set bell-style none")
+	ex.Add("set bell-style none")
+	ex.Add("s/c/d/")
+	ex.ResetWalk()
+
+	steps := []struct {
+		cmdline string
+		forward bool
+		want    string
+	}{
+		{cmdline: "s/", want: "s/c/d/"},
+		{cmdline: "s/c/d/", want: "s/a/b/"},
+		{cmdline: "s/a/b/", want: "s/a/b/"},
+		{cmdline: "s/a/b/", forward: true, want: "s/c/d/"},
+		{cmdline: "s/c/d/", forward: true, want: "s/"},
+		{cmdline: "s/", forward: true, want: "s/"},
+		{cmdline: "", want: "s/c/d/"},
+		{cmdline: "s/c/d/", want: "set bell-style none"},
+	}
+
+	for i, step := range steps {
+		if got := ex.Walk(step.cmdline, step.forward); got != step.want {
+			t.Fatalf("step %d: Ex.Walk() = %q, want %q", i, got, step.want)
+		}
+
+		// Walking the history from the end again.
+		if i == 5 {
+			ex.ResetWalk()
+		}
+	}
+}
+
+func TestEx_Complete(t *testing.T) {
+	vars := []string{"bell-style", "blink-matching-paren", "mark-directories"}
+
+	tests := []struct {
+		name    string
+		cmdline string
+		want    []string
+	}{
+		{name: "Command names", cmdline: "%s", want: []string{"%set", "%substitute", "%set"}},
+		{name: "Unique command", cmdline: "nor", want: []string{"normal", "normal"}},
+		{name: "Variables", cmdline: "set b", want: []string{"set bell-style", "set blink-matching-paren"}},
+		{name: "Negated variables", cmdline: "se bell-style nomark", want: []string{"se bell-style nomark-directories"}},
+		{name: "No candidates", cmdline: "set x", want: []string{"set x"}},
+		{name: "No variable values", cmdline: "set bell-style=a", want: []string{"set bell-style=a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ex := new(Ex)
+			cmdline := test.cmdline
+
+			for _, want := range test.want {
+				if cmdline = ex.Complete(cmdline, vars); cmdline != want {
+					t.Fatalf("Ex.Complete() = %q, want %q", cmdline, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// Errors returned by history expansion.
+var (
+	ErrEventNotFound  = errors.New("event not found")
+	ErrBadWordSpec    = errors.New("bad word specifier")
+	ErrBadModifier    = errors.New("unrecognized history modifier")
+	ErrSubstFailed    = errors.New("substitution failed")
+	ErrNoPreviousSubs = errors.New("no previous substitution")
+)
+
+// Expander performs csh-style history expansion. It keeps the state shared by
+// successive expansions: the last substitution, and the last ?string? search.
+type Expander struct {
+	lastOld    string
+	lastNew    string
+	lastSearch string
+}
+
+// Expand performs history expansion on a line, using the lines of a history source:
+//
+//   - Event designators: !! (previous line), !n (line n), !-n (n lines back),
+//     !string (last line starting with string), !?string[?] (last line containing
+//     string), !# (the line typed so far), and ^old^new[^] (quick substitution).
+//   - Word designators, following the event with a ':' (optional before ^, $, *, -
+//     and %), or alone (for the previous line): n, ^, $, %, x-y, -y, x-, x* and *.
+//   - Modifiers, each following a ':': h, t, r, e, p, q, x, s/old/new/, &, and the
+//     g/a (all occurrences) and G (each word) prefixes for s and &.
+//
+// History expansion is not performed inside single quotes, after a backslash, nor
+// when the '!' is followed by a blank, a '=' or a '('. If the :p modifier is used,
+// print is true: the line should be displayed for editing, but not accepted.
+func (e *Expander) Expand(line string, source Source) (expanded string, print bool, err error) {
+	runes := []rune(line)
+
+	if source == nil {
+		source = NewInMemoryHistory()
+	}
+
+	// Quick substitution is a shorthand for !!:s^old^new^
+	if len(runes) > 1 && runes[0] == '^' {
+		runes = append([]rune("!!:s"), runes...)
+	}
+
+	var (
+		out            strings.Builder
+		single, double bool
+	)
+
+	for pos := 0; pos < len(runes); pos++ {
+		char := runes[pos]
+
+		switch {
+		case char == '\\' && !single && pos+1 < len(runes):
+			out.WriteRune(char)
+			out.WriteRune(runes[pos+1])
+			pos++
+
+		case char == '\'' && !double:
+			single = !single
+			out.WriteRune(char)
+
+		case char == '"' && !single:
+			double = !double
+			out.WriteRune(char)
+
+		case char == '!' && !single && expandable(runes, pos, double):
+			exp := &expansion{Expander: e, runes: runes, pos: pos + 1, source: source, typed: out.String()}
+
+			text, err := exp.expand()
+			if err != nil {
+				return line, false, err
+			}
+
+			out.WriteString(text)
+			print = print || exp.print
+			pos = exp.pos - 1
+
+		default:
+			out.WriteRune(char)
+		}
+	}
+
+	return out.String(), print, nil
+}
+
+// expandable returns true if the '!' at pos starts a history expansion.
+func expandable(runes []rune, pos int, double bool) bool {
+	if pos+1 >= len(runes) {
+		return false
+	}
+
+	next := runes[pos+1]
+
+	if next == '=' || (double && next == '"') {
+		return false
+	}
+
+	return next == ':' || !isEventEnd(next)
+}
+
+// expansion is a single history expansion being parsed.
+type expansion struct {
+	*Expander
+	runes  []rune
+	pos    int
+	source Source
+	typed  string
+	print  bool
+}
+
+func (x *expansion) expand() (string, error) {
+	event, err := x.event()
+	if err != nil {
+		return "", err
+	}
+
+	words := splitWords(event)
+
+	text, err := x.words(words)
+	if err != nil {
+		return "", err
+	}
+
+	return x.modifiers(text)
+}
+
+// event parses an event designator and returns the history line it refers to.
+func (x *expansion) event() (string, error) {
+	start := x.pos - 1
+	char := x.runes[x.pos]
+
+	switch {
+	case char == '!':
+		x.pos++
+		return x.line(x.source.Len()-1, "!!")
+
+	case char == '#':
+		x.pos++
+		return x.typed, nil
+
+	case strings.ContainsRune(":^$*%", char):
+		// A word designator alone refers to the previous line.
+		return x.line(x.source.Len()-1, "!!")
+
+	case unicode.IsDigit(char), char == '-' && x.pos+1 < len(x.runes) && unicode.IsDigit(x.runes[x.pos+1]):
+		x.pos++
+		for x.pos < len(x.runes) && unicode.IsDigit(x.runes[x.pos]) {
+			x.pos++
+		}
+
+		spec := string(x.runes[start:x.pos])
+		num, _ := strconv.Atoi(spec[1:])
+
+		if num < 0 {
+			return x.line(x.source.Len()+num, spec)
+		}
+
+		return x.line(num-1, spec)
+
+	case char == '?':
+		x.pos++
+		begin := x.pos
+
+		for x.pos < len(x.runes) && x.runes[x.pos] != '?' && x.runes[x.pos] != '\n' {
+			x.pos++
+		}
+
+		search := string(x.runes[begin:x.pos])
+		if x.pos < len(x.runes) && x.runes[x.pos] == '?' {
+			x.pos++
+		}
+
+		if search == "" {
+			search = x.lastSearch
+		}
+
+		x.lastSearch = search
+
+		return x.search(string(x.runes[start:x.pos]), func(line string) bool {
+			return search != "" && strings.Contains(line, search)
+		})
+
+	default:
+		begin := x.pos
+		for x.pos < len(x.runes) && !isEventEnd(x.runes[x.pos]) {
+			x.pos++
+		}
+
+		prefix := string(x.runes[begin:x.pos])
+
+		return x.search(string(x.runes[start:x.pos]), func(line string) bool {
+			return strings.HasPrefix(line, prefix)
+		})
+	}
+}
+
+// line returns the history line at index i.
+func (x *expansion) line(i int, spec string) (string, error) {
+	if i < 0 || i >= x.source.Len() {
+		return "", fmt.Errorf("%s: %w", spec, ErrEventNotFound)
+	}
+
+	line, err := x.source.GetLine(i)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", spec, ErrEventNotFound)
+	}
+
+	return line, nil
+}
+
+// search returns the most recent history line matching.
+func (x *expansion) search(spec string, match func(line string) bool) (string, error) {
+	for i := x.source.Len() - 1; i >= 0; i-- {
+		if line, err := x.source.GetLine(i); err == nil && match(line) {
+			return line, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: %w", spec, ErrEventNotFound)
+}
+
+// words parses an optional word designator and returns the designated words.
+func (x *expansion) words(words []string) (string, error) {
+	if x.pos >= len(x.runes) {
+		return strings.Join(words, " "), nil
+	}
+
+	switch char := x.runes[x.pos]; {
+	case char == ':' && x.pos+1 < len(x.runes) && isWordStart(x.runes[x.pos+1]):
+		x.pos++
+	case strings.ContainsRune("^$*%", char), char == '-' && x.pos+1 < len(x.runes) && isWordStart(x.runes[x.pos+1]):
+	default:
+		return strings.Join(words, " "), nil
+	}
+
+	start := x.pos
+	last := len(words) - 1
+
+	first, end := x.wordRange(words)
+
+	// Only * and x* can designate no words.
+	if first > end && x.runes[x.pos-1] == '*' {
+		return "", nil
+	}
+
+	if first < 0 || end > last || first > end {
+		return "", fmt.Errorf("%s: %w", string(x.runes[start:x.pos]), ErrBadWordSpec)
+	}
+
+	return strings.Join(words[first:end+1], " "), nil
+}
+
+// wordRange parses a word designator into a range of word indexes.
+func (x *expansion) wordRange(words []string) (first, end int) {
+	last := len(words) - 1
+
+	switch x.runes[x.pos] {
+	case '^':
+		x.pos++
+		return 1, 1
+	case '$':
+		x.pos++
+		return last, last
+	case '*':
+		x.pos++
+		return 1, last
+	case '%':
+		x.pos++
+
+		// The word matched by the last ?string? search.
+		for i, word := range words {
+			if x.lastSearch != "" && strings.Contains(word, x.lastSearch) {
+				return i, i
+			}
+		}
+
+		return -1, -1
+	case '-':
+		first = 0
+	default:
+		first = x.number()
+	}
+
+	if x.pos >= len(x.runes) {
+		return first, first
+	}
+
+	switch x.runes[x.pos] {
+	case '*':
+		x.pos++
+		return first, last
+	case '-':
+		x.pos++
+	default:
+		return first, first
+	}
+
+	switch {
+	case x.pos < len(x.runes) && x.runes[x.pos] == '$':
+		x.pos++
+		return first, last
+	case x.pos < len(x.runes) && unicode.IsDigit(x.runes[x.pos]):
+		return first, x.number()
+	default:
+		// x- is like x-$, without the last word.
+		return first, last - 1
+	}
+}
+
+func (x *expansion) number() int {
+	begin := x.pos
+	for x.pos < len(x.runes) && unicode.IsDigit(x.runes[x.pos]) {
+		x.pos++
+	}
+
+	num, _ := strconv.Atoi(string(x.runes[begin:x.pos]))
+
+	return num
+}
+
+// modifiers parses and applies all modifiers following the designated words.
+func (x *expansion) modifiers(text string) (string, error) {
+	for x.pos+1 < len(x.runes) && x.runes[x.pos] == ':' {
+		x.pos++
+		start := x.pos
+
+		var global, each bool
+
+		switch x.runes[x.pos] {
+		case 'g', 'a':
+			global = true
+			x.pos++
+		case 'G':
+			each = true
+			x.pos++
+		}
+
+		if x.pos >= len(x.runes) {
+			return "", fmt.Errorf(":%s: %w", string(x.runes[start:x.pos]), ErrBadModifier)
+		}
+
+		modifier := x.runes[x.pos]
+		x.pos++
+
+		if (global || each) && modifier != 's' && modifier != '&' {
+			return "", fmt.Errorf(":%s: %w", string(x.runes[start:x.pos]), ErrBadModifier)
+		}
+
+		var err error
+
+		switch modifier {
+		case 'h':
+			if idx := strings.LastIndex(text, "/"); idx >= 0 {
+				text = text[:idx]
+			}
+		case 't':
+			text = text[strings.LastIndex(text, "/")+1:]
+		case 'r':
+			if dot := strings.LastIndex(text, "."); dot > strings.LastIndex(text, "/") {
+				text = text[:dot]
+			}
+		case 'e':
+			if dot := strings.LastIndex(text, "."); dot > strings.LastIndex(text, "/") {
+				text = text[dot:]
+			}
+		case 'p':
+			x.print = true
+		case 'q':
+			text = quote(text)
+		case 'x':
+			words := strings.Fields(text)
+			for i, word := range words {
+				words[i] = quote(word)
+			}
+
+			text = strings.Join(words, " ")
+		case 's':
+			if err = x.parseSubstitution(); err == nil {
+				text, err = x.substitute(text, global, each)
+			}
+		case '&':
+			text, err = x.substitute(text, global, each)
+		default:
+			err = fmt.Errorf(":%s: %w", string(x.runes[start:x.pos]), ErrBadModifier)
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return text, nil
+}
+
+// parseSubstitution parses the old and new strings of a s/old/new/ modifier,
+// with any delimiter, and saves them as the last substitution. The final
+// delimiter is optional at the end of the line.
+func (x *expansion) parseSubstitution() error {
+	if x.pos >= len(x.runes) {
+		return fmt.Errorf(":s: %w", ErrBadModifier)
+	}
+
+	delim := x.runes[x.pos]
+	x.pos++
+
+	old := x.delimited(delim, false)
+	repl := x.delimited(delim, true)
+
+	// An empty old string uses the last one, or the last search.
+	switch {
+	case old != "":
+	case x.lastOld != "":
+		old = x.lastOld
+	case x.lastSearch != "":
+		old = x.lastSearch
+	default:
+		return ErrNoPreviousSubs
+	}
+
+	// In the replacement, & stands for the old string.
+	var replacement strings.Builder
+
+	for i := 0; i < len(repl); i++ {
+		switch {
+		case repl[i] == '\\' && i+1 < len(repl) && repl[i+1] == '&':
+			replacement.WriteByte('&')
+			i++
+		case repl[i] == '&':
+			replacement.WriteString(old)
+		default:
+			replacement.WriteByte(repl[i])
+		}
+	}
+
+	x.lastOld, x.lastNew = old, replacement.String()
+
+	return nil
+}
+
+// delimited returns the text up to the next (unescaped) delimiter, and skips it.
+// Escaped ampersands are kept escaped if keepAmp is true.
+func (x *expansion) delimited(delim rune, keepAmp bool) string {
+	var text strings.Builder
+
+	for x.pos < len(x.runes) {
+		char := x.runes[x.pos]
+		x.pos++
+
+		switch {
+		case char == delim:
+			return text.String()
+		case char == '\\' && x.pos < len(x.runes) && x.runes[x.pos] == delim:
+			text.WriteRune(delim)
+			x.pos++
+		case char == '\\' && x.pos < len(x.runes) && x.runes[x.pos] == '&' && !keepAmp:
+			text.WriteRune('&')
+			x.pos++
+		default:
+			text.WriteRune(char)
+		}
+	}
+
+	return text.String()
+}
+
+// substitute applies the last substitution to the text.
+func (x *expansion) substitute(text string, global, each bool) (string, error) {
+	if x.lastOld == "" {
+		return "", ErrNoPreviousSubs
+	}
+
+	switch {
+	case global:
+		if !strings.Contains(text, x.lastOld) {
+			return "", fmt.Errorf("%s: %w", x.lastOld, ErrSubstFailed)
+		}
+
+		return strings.ReplaceAll(text, x.lastOld, x.lastNew), nil
+
+	case each:
+		words := strings.Split(text, " ")
+		for i, word := range words {
+			words[i] = strings.Replace(word, x.lastOld, x.lastNew, 1)
+		}
+
+		return strings.Join(words, " "), nil
+
+	default:
+		if !strings.Contains(text, x.lastOld) {
+			return "", fmt.Errorf("%s: %w", x.lastOld, ErrSubstFailed)
+		}
+
+		return strings.Replace(text, x.lastOld, x.lastNew, 1), nil
+	}
+}
+
+// splitWords splits a history line into words, as the shell does:
+// quotes are kept, and operators and redirections are words of their own.
+func splitWords(line string) []string {
+	var words []string
+
+	for _, tok := range strutil.Lex(line) {
+		if tok.Joined && len(words) > 0 {
+			words[len(words)-1] += tok.Text
+			continue
+		}
+
+		words = append(words, tok.Text)
+	}
+
+	return words
+}
+
+// quote single-quotes a string for the shell.
+func quote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// isEventEnd returns true if the character ends a !string event designator.
+func isEventEnd(char rune) bool {
+	return unicode.IsSpace(char) || strings.ContainsRune(":;&|()<>'\"`", char)
+}
+
+// isWordStart returns true if the character can start a word designator after a ':'.
+func isWordStart(char rune) bool {
+	return unicode.IsDigit(char) || strings.ContainsRune("^$*%-", char)
+}
@@ -0,0 +1,165 @@
+package history
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestHistory(lines ...string) Source {
+	hist := NewInMemoryHistory()
+	for _, line := range lines {
+		hist.Write(line)
+	}
+
+	return hist
+}
+
+func TestExpander_Expand(t *testing.T) {
+	hist := newTestHistory(
+		"ls -l /usr/local/lib",
+		"cat /etc/hosts.conf",
+		"git commit -m 'fix: it works' --amend",
+		"echo foo bar baz",
+	)
+
+	tests := []struct {
+		name  string
+		line  string
+		want  string
+		print bool
+		err   error
+	}{
+		// No expansion
+		{name: "No expansion", line: "echo hello", want: "echo hello"},
+		{name: "Trailing bang", line: "echo hi!", want: "echo hi!"},
+		{name: "Bang blank", line: "echo ! foo", want: "echo ! foo"},
+		{name: "Bang equal", line: "[ a != b ]", want: "[ a != b ]"},
+		{name: "Bang paren", line: "ls !(foo)", want: "ls !(foo)"},
+		{name: "Single quotes", line: "echo '!!'", want: "echo '!!'"},
+		{name: "Escaped bang", line: `echo \!!`, want: `echo \!!`},
+		{name: "Bang before quote", line: `echo "hi!"`, want: `echo "hi!"`},
+
+		// Event designators
+		{name: "Previous line", line: "!!", want: "echo foo bar baz"},
+		{name: "Previous line in text", line: "sudo !! | less", want: "sudo echo foo bar baz | less"},
+		{name: "Double quotes", line: `echo "!!"`, want: `echo "echo foo bar baz"`},
+		{name: "Absolute line", line: "!2", want: "cat /etc/hosts.conf"},
+		{name: "Relative line", line: "!-3", want: "cat /etc/hosts.conf"},
+		{name: "Prefix", line: "!ca", want: "cat /etc/hosts.conf"},
+		{name: "Prefix most recent", line: "!e", want: "echo foo bar baz"},
+		{name: "Substring", line: "!?hosts?", want: "cat /etc/hosts.conf"},
+		{name: "Substring unterminated", line: "!?local", want: "ls -l /usr/local/lib"},
+		{name: "Typed so far", line: "cp a.txt !#:1.bak", want: "cp a.txt a.txt.bak"},
+		{name: "Line zero", line: "!0", err: ErrEventNotFound},
+		{name: "Line out of range", line: "!5", err: ErrEventNotFound},
+		{name: "Relative out of range", line: "!-5", err: ErrEventNotFound},
+		{name: "Prefix not found", line: "!nope", err: ErrEventNotFound},
+		{name: "Substring not found", line: "!?nope?", err: ErrEventNotFound},
+
+		// Word designators
+		{name: "Last word", line: "vim !$", want: "vim baz"},
+		{name: "First argument", line: "vim !^", want: "vim foo"},
+		{name: "All arguments", line: "print !*", want: "print foo bar baz"},
+		{name: "Word zero", line: "!!:0", want: "echo"},
+		{name: "Word index", line: "!!:2", want: "bar"},
+		{name: "Word range", line: "!!:1-2", want: "foo bar"},
+		{name: "Word range to last", line: "!!:2-$", want: "bar baz"},
+		{name: "Word range from zero", line: "!!:-2", want: "echo foo bar"},
+		{name: "Word range no last", line: "!!:1-", want: "foo bar"},
+		{name: "Word star from", line: "!!:2*", want: "bar baz"},
+		{name: "Word without colon", line: "!ls$", err: ErrEventNotFound},
+		{name: "Event then last", line: "!cat:$", want: "/etc/hosts.conf"},
+		{name: "Numbered then word", line: "!1:2", want: "/usr/local/lib"},
+		{name: "Quoted word", line: "!git:3", want: "'fix: it works'"},
+		{name: "Alone word designator", line: "!:1", want: "foo"},
+		{name: "Search word", line: "!?hosts?:%", want: "/etc/hosts.conf"},
+		{name: "Search word not in event", line: "!?hosts? !%", err: ErrBadWordSpec},
+		{name: "Bad word index", line: "!!:9", err: ErrBadWordSpec},
+		{name: "Bad word range", line: "!!:3-1", err: ErrBadWordSpec},
+
+		// Modifiers
+		{name: "Head", line: "cd !ls:$:h", want: "cd /usr/local"},
+		{name: "Tail", line: "echo !ls:$:t", want: "echo lib"},
+		{name: "Root", line: "echo !cat:$:r", want: "echo /etc/hosts"},
+		{name: "Extension", line: "echo !cat:$:e", want: "echo .conf"},
+		{name: "Chained", line: "echo !cat:$:t:r", want: "echo hosts"},
+		{name: "Quote", line: "echo !!:q", want: "echo 'echo foo bar baz'"},
+		{name: "Quote words", line: "echo !!:x", want: "echo 'echo' 'foo' 'bar' 'baz'"},
+		{name: "Print", line: "!!:p", want: "echo foo bar baz", print: true},
+		{name: "Substitute", line: "!!:s/foo/qux/", want: "echo qux bar baz"},
+		{name: "Substitute first only", line: "!ls:s/l/L/", want: "Ls -l /usr/local/lib"},
+		{name: "Substitute global", line: "!ls:gs/l/L/", want: "Ls -L /usr/LocaL/Lib"},
+		{name: "Substitute global alias", line: "!ls:as/l/L/", want: "Ls -L /usr/LocaL/Lib"},
+		{name: "Substitute each word", line: "!ls:Gs/l/L/", want: "Ls -L /usr/Local/lib"},
+		{name: "Substitute delimiter", line: "!!:s|foo|a/b|", want: "echo a/b bar baz"},
+		{name: "Substitute ampersand", line: "!!:s/foo/&&/", want: "echo foofoo bar baz"},
+		{name: "Substitute escaped ampersand", line: `!!:s/foo/\&/`, want: "echo & bar baz"},
+		{name: "Substitute unterminated", line: "!!:s/foo/new stuff", want: "echo new stuff bar baz"},
+		{name: "Substitute failed", line: "!!:s/nope/x/", err: ErrSubstFailed},
+		{name: "Bad modifier", line: "!!:z", err: ErrBadModifier},
+		{name: "Bad global modifier", line: "!!:gh", err: ErrBadModifier},
+
+		// Quick substitution
+		{name: "Quick substitution", line: "^foo^qux^", want: "echo qux bar baz"},
+		{name: "Quick substitution unterminated", line: "^bar^x", want: "echo foo x baz"},
+		{name: "Quick substitution failed", line: "^nope^x", err: ErrSubstFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expander := new(Expander)
+
+			got, print, err := expander.Expand(test.line, hist)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expander.Expand() error = %v, want %v", err, test.err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			if got != test.want || print != test.print {
+				t.Errorf("Expander.Expand() = (%q, %v), want (%q, %v)", got, print, test.want, test.print)
+			}
+		})
+	}
+}
+
+func TestExpander_ExpandState(t *testing.T) {
+	hist := newTestHistory("cat foo.txt", "echo bar.txt")
+	expander := new(Expander)
+
+	steps := []struct {
+		line string
+		want string
+		err  error
+	}{
+		{line: "!!:&", err: ErrNoPreviousSubs},
+		{line: "!!:s/txt/md/", want: "echo bar.md"},
+		{line: "!-2:&", want: "cat foo.md"},
+		{line: "!?foo?", want: "cat foo.txt"},
+		{line: "!!:s//baz/", want: "echo bar.baz"}, // Last old string "txt" is reused.
+		{line: "!cat:g&", want: "cat foo.baz"},
+	}
+
+	for _, step := range steps {
+		got, _, err := expander.Expand(step.line, hist)
+		if !errors.Is(err, step.err) {
+			t.Fatalf("Expander.Expand(%q) error = %v, want %v", step.line, err, step.err)
+		}
+
+		if step.err == nil && got != step.want {
+			t.Errorf("Expander.Expand(%q) = %q, want %q", step.line, got, step.want)
+		}
+	}
+}
+
+func TestExpander_ExpandEmptyHistory(t *testing.T) {
+	expander := new(Expander)
+
+	for _, line := range []string{"!!", "!$", "!1", "!-1", "!foo", "^a^b"} {
+		if _, _, err := expander.Expand(line, nil); !errors.Is(err, ErrEventNotFound) {
+			t.Errorf("Expander.Expand(%q) error = %v, want %v", line, err, ErrEventNotFound)
+		}
+	}
+}
@@ -35,6 +35,9 @@ type Sources struct {
 	last    inputrc.Bind                    // The last command being ran.
 	lines   map[string]map[int]*lineHistory // Each line in each history source has its own buffer history.
 
+	// History expansion
+	expander Expander // Keeps the last substitution and search.
+
 	// Lines accepted
 	infer      bool      // If the last command ran needs to infer the history line.
 	accepted   bool      // The line has been accepted and must be returned.
@@ -204,8 +207,8 @@ func (h *Sources) Walk(pos int) {
 
 	// When there is an available change history for
 	// this line, use it instead of the fetched line.
-	if hist := h.getLineHistory(); hist != nil && len(hist.items) > 0 {
-		line = hist.items[len(hist.items)-1].line
+	if hist := h.getLineHistory(); hist != nil && hist.current != nil {
+		line = hist.line
 	} else if line, err = history.GetLine(history.Len() - h.hpos); err != nil {
 		h.hint.Set(color.FgRed + "history error: " + err.Error())
 		return
@@ -468,6 +471,12 @@ func (h *Sources) Suggest(line *core.Line) core.Line {
 	return core.Line([]rune(suggested))
 }
 
+// Expand performs csh-style history expansion on a line with the current history
+// source, and returns the expanded line. See Expander.Expand for details.
+func (h *Sources) Expand(line string) (expanded string, print bool, err error) {
+	return h.expander.Expand(line, h.Current())
+}
+
 // Complete returns completions with the current history source values.
 // If forward is true, the completions are proposed from the most ancient
 // line in the history source to the most recent. If filter is true,
@@ -655,13 +664,12 @@ func (h *Sources) getLine(line *core.Line, cur *core.Cursor) (*core.Line, *core.
 		}
 
 		lh := hist[0]
-		if lh == nil || len(lh.items) == 0 {
+		if lh == nil || lh.current == nil {
 			return line, cur
 		}
 
-		undo := lh.items[len(lh.items)-1]
-		line.Set([]rune(undo.line)...)
-		cur.Set(undo.pos)
+		line.Set([]rune(lh.line)...)
+		cur.Set(lh.current.pos)
 	}
 
 	if cur == nil {
@@ -1,25 +1,60 @@
 package history
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/completion"
 	"github.com/reeflective/readline/internal/core"
 )
 
+// checkpointInterval is the maximum number of edits separating an undo
+// state from its closest checkpoint ancestor, thus the maximum number of
+// edits to apply when restoring a state.
+const checkpointInterval = 32
+
 // lineHistory contains all state changes for a given input line,
 // whether it is the current input line or one of the history ones.
+// States are stored as a tree (like vim's undo tree): when a change
+// is made after undoing, a new branch is created and older ones kept.
+//
+// States only store the edit made to their parent state, except for
+// checkpoints (including the root state) which store their full line.
 type lineHistory struct {
-	pos   int
-	items []undoItem
+	states   []*undoState // All states, in chronological order.
+	current  *undoState   // The state the line was last saved in or restored to.
+	line     string       // The line of the current state.
+	browsing bool         // States are proposed as completions.
 }
 
-type undoItem struct {
-	line string
-	pos  int
+// undoState is a saved state of the line, and a node of the undo tree.
+type undoState struct {
+	edit                  // Changes made to the parent line, if not a checkpoint.
+	line       string     // The full line, if a checkpoint.
+	checkpoint bool       // The state stores its full line.
+	depth      int        // Number of edits since the last checkpoint.
+	pos        int        // Cursor position.
+	seq        int        // Chronological number of the state.
+	time       time.Time  // When the state was saved.
+	parent     *undoState // State the edit was made to.
+	children   []*undoState
+	redo       *undoState // Child on the branch last visited, restored by redo.
+}
+
+// edit is the replacement of a span of a line by some text.
+type edit struct {
+	start, end int    // Byte offsets of the replaced span.
+	text       string // Text replacing the span.
 }
 
 // Save saves the current line and cursor position as an undo state item.
 // If this was called while the shell was in the middle of its undo history
-// (eg. the caller has undone one or more times), all undone steps are dropped.
+// (eg. the caller has undone one or more times), a new branch of changes is
+// started in the undo tree, and the undone states are kept in their own one.
 func (h *Sources) Save() {
 	defer h.Reset()
 
@@ -27,37 +62,30 @@ func (h *Sources) Save() {
 		return
 	}
 
-	// Get the undo states for the current line.
+	// History lines have no undo states until they are modified.
 	line := h.getLineHistory()
 	if line == nil {
-		return
-	}
+		if original, found := h.historyLine(); found && original == string(*h.line) {
+			return
+		}
 
-	// When the line is identical to the previous undo, we just update
-	// the cursor position if it's a different one.
-	if len(line.items) > 0 && line.items[len(line.items)-1].line == string(*h.line) {
-		line.items[len(line.items)-1].pos = h.cursor.Pos()
-		return
+		line = h.editLineHistory()
 	}
 
-	// When we add an item to the undo history, the history
-	// is cut from the current undo hist position onwards.
-	if line.pos > len(line.items) {
-		line.pos = len(line.items)
-	}
+	// If the line was replaced with one of the states
+	// proposed as completions, we go back to this state.
+	if line.browsing && line.current != nil && line.line != string(*h.line) {
+		line.browsing = false
 
-	line.items = line.items[:len(line.items)-line.pos]
+		if state := line.find(string(*h.line)); state != nil {
+			line.goTo(state)
+			state.pos = h.cursor.Pos()
 
-	// Make a copy of the cursor and ensure its position.
-	cur := core.NewCursor(h.line)
-	cur.Set(h.cursor.Pos())
-	cur.CheckCommand()
+			return
+		}
+	}
 
-	// And save the item.
-	line.items = append(line.items, undoItem{
-		line: string(*h.line),
-		pos:  cur.Pos(),
-	})
+	h.save(line)
 }
 
 // SkipSave will not save the current line when the target command is done
@@ -77,83 +105,98 @@ func (h *Sources) SaveWithCommand(bind inputrc.Bind) {
 }
 
 // Undo restores the line and cursor position to their last saved state.
+// Unsaved changes to the line are saved first, so that they can be redone.
 func (h *Sources) Undo() {
 	h.skip = true
 	h.undoing = true
 
 	// Get the undo states for the current line.
-	line := h.getLineHistory()
-	if line == nil || len(line.items) == 0 {
+	line := h.editLineHistory()
+	if line == nil {
 		return
 	}
 
-	var undo undoItem
-
-	// When undoing, we loop through preceding undo items
-	// as long as they are identical to the current line.
-	for {
-		line.pos++
+	h.save(line)
 
-		// Exit if we reached the end.
-		if line.pos > len(line.items) {
-			line.pos = len(line.items)
-			return
-		}
+	// When undoing, we walk up the undo tree as
+	// long as states are identical to the current line.
+	undo := line.current
+	for undo.parent != nil && line.lineOf(undo) == string(*h.line) {
+		undo = undo.parent
+	}
 
-		// Break as soon as we find a non-matching line.
-		undo = line.items[len(line.items)-line.pos]
-		if undo.line != string(*h.line) {
-			break
-		}
+	if undo == line.current {
+		return
 	}
 
-	// Use the undo we found
-	h.line.Set([]rune(undo.line)...)
-	h.cursor.Set(undo.pos)
+	h.restore(line, undo)
 }
 
 // Revert goes back to the initial state of the line, which is what it was
 // like when the shell started reading user input. Note that this state might
 // be a line that was inferred, accept-and-held from the previous readline run.
+// The changes are not dropped, and can be restored with redo or undo-later.
 func (h *Sources) Revert() {
 	line := h.getLineHistory()
-	if line == nil || len(line.items) == 0 {
+	if line == nil || len(line.states) == 0 {
 		return
 	}
 
-	// Reuse the first saved state.
-	undo := line.items[0]
+	h.save(line)
 
-	h.line.Set([]rune(undo.line)...)
-	h.cursor.Set(undo.pos)
-
-	// And reset everything
-	line.items = make([]undoItem, 0)
+	// Reuse the first saved state.
+	h.restore(line, line.states[0])
 
-	h.Reset()
+	h.skip = true
+	h.undoing = true
 }
 
-// Redo cancels an undo action if any has been made, or if
-// at the begin of the undo history, restores the original
-// line's contents as their were before starting undoing.
+// Redo cancels an undo action if any has been made, restoring the
+// state of the line on the branch of the undo tree last visited.
 func (h *Sources) Redo() {
 	h.skip = true
 	h.undoing = true
 
 	line := h.getLineHistory()
-	if line == nil || len(line.items) == 0 {
+	if line == nil || line.current == nil || line.current.redo == nil {
 		return
 	}
 
-	line.pos--
+	h.restore(line, line.current.redo)
+}
 
-	if line.pos < 1 {
+// UndoEarlier restores the state of the line saved before the current
+// one in time, regardless of the undo tree branch it belongs to.
+func (h *Sources) UndoEarlier() {
+	h.skip = true
+	h.undoing = true
+
+	line := h.editLineHistory()
+	if line == nil {
 		return
 	}
 
-	undo := line.items[len(line.items)-line.pos]
-	h.line.Set([]rune(undo.line)...)
-	h.cursor.Set(undo.pos)
+	h.save(line)
+
+	if line.current.seq == 0 {
+		return
+	}
+
+	h.restore(line, line.states[line.current.seq-1])
+}
+
+// UndoLater restores the state of the line saved after the current
+// one in time, regardless of the undo tree branch it belongs to.
+func (h *Sources) UndoLater() {
+	h.skip = true
+	h.undoing = true
+
+	line := h.getLineHistory()
+	if line == nil || line.current == nil || line.current.seq == len(line.states)-1 {
+		return
+	}
+
+	h.restore(line, line.states[line.current.seq+1])
 }
 
 // Last returns the last command ran by the shell.
@@ -161,32 +204,244 @@ func (h *Sources) Last() inputrc.Bind {
 	return h.last
 }
 
-// Pos returns the current position in the undo history, which is
-// equal to its length minus the number of previous undo calls.
+// Pos returns the current position in the undo history, which is equal
+// to the number of states that can be restored with consecutive redos.
 func (h *Sources) Pos() int {
 	lh := h.getLineHistory()
-	if lh == nil {
+	if lh == nil || lh.current == nil {
 		return 0
 	}
 
-	return lh.pos
+	var pos int
+
+	for state := lh.current.redo; state != nil; state = state.redo {
+		pos++
+	}
+
+	return pos
 }
 
 // Reset will reset the current position in the list
 // of undo items, but will not delete any of them.
 func (h *Sources) Reset() {
 	h.skip = false
+	h.undoing = false
+}
 
-	line := h.getLineHistory()
+// CompleteUndo returns all undo states of the current line as completions,
+// the most recent first, with a preview of each. The states replace the whole
+// line, but are not filtered with it as a prefix. If the line is replaced
+// with one of them, the undo history goes back to this state (the most
+// recent one if several states have the same line).
+func CompleteUndo(h *Sources) completion.Values {
+	line := h.editLineHistory()
 	if line == nil {
+		return completion.Values{}
+	}
+
+	// Unsaved changes are a state of their own.
+	h.save(line)
+
+	line.browsing = true
+
+	values := completion.AddRaw(line.candidates())
+	values.NoSort["*"] = true
+	values.ListLong["*"] = true
+	values.PREFIX = string(*h.line)
+	values.NoFilter = true
+	values.Preview = func(comp completion.Candidate) string {
+		return comp.Value
+	}
+
+	return values
+}
+
+// save adds the current line and cursor to the undo tree, as a child of the
+// current state, or just updates the cursor position if the line is the same.
+func (h *Sources) save(line *lineHistory) {
+	// When the line is identical to the current undo, we just
+	// update the cursor position if it's a different one.
+	if line.current != nil && line.line == string(*h.line) {
+		line.current.pos = h.cursor.Pos()
 		return
 	}
 
-	if !h.undoing {
-		line.pos = 0
+	// Make a copy of the cursor and ensure its position.
+	cur := core.NewCursor(h.line)
+	cur.Set(h.cursor.Pos())
+	cur.CheckCommand()
+
+	// And save the item.
+	line.add(string(*h.line), cur.Pos())
+}
+
+// restore makes an undo state the current one, and sets the line and cursor to it.
+func (h *Sources) restore(line *lineHistory, undo *undoState) {
+	line.goTo(undo)
+
+	h.line.Set([]rune(line.line)...)
+	h.cursor.Set(undo.pos)
+}
+
+// historyLine returns the history source line being edited, if any.
+func (h *Sources) historyLine() (line string, found bool) {
+	history := h.Current()
+	if h.hpos < 1 || history == nil {
+		return "", false
+	}
+
+	line, err := history.GetLine(history.Len() - h.hpos)
+
+	return line, err == nil
+}
+
+// add adds a new state as a child of the current one, and makes it current.
+func (lh *lineHistory) add(line string, pos int) {
+	state := &undoState{
+		pos:    pos,
+		seq:    len(lh.states),
+		time:   time.Now(),
+		parent: lh.current,
 	}
 
-	h.undoing = false
+	if lh.current != nil {
+		lh.current.children = append(lh.current.children, state)
+		lh.current.redo = state
+		state.depth = lh.current.depth + 1
+	}
+
+	// Only store the changes to the parent line, unless
+	// this state is too far from the last checkpoint.
+	if state.parent == nil || state.depth >= checkpointInterval {
+		state.checkpoint = true
+		state.depth = 0
+		state.line = line
+	} else {
+		state.edit = diff(lh.line, line)
+	}
+
+	lh.states = append(lh.states, state)
+	lh.current = state
+	lh.line = line
+}
+
+// goTo makes a state the current one, and makes all its
+// ancestors follow its branch when redoing changes.
+func (lh *lineHistory) goTo(state *undoState) {
+	lh.line = lh.lineOf(state)
+	lh.current = state
+
+	for ; state.parent != nil; state = state.parent {
+		state.parent.redo = state
+	}
+}
+
+// lineOf returns the line of a state, by applying the edits
+// made since its closest checkpoint ancestor to the latter.
+func (lh *lineHistory) lineOf(state *undoState) string {
+	if state == lh.current {
+		return lh.line
+	}
+
+	edits := make([]edit, 0, state.depth)
+
+	for ; !state.checkpoint; state = state.parent {
+		edits = append(edits, state.edit)
+	}
+
+	line := state.line
+
+	for i := len(edits) - 1; i >= 0; i-- {
+		line = edits[i].apply(line)
+	}
+
+	return line
+}
+
+// candidates returns the undo states as completion candidates,
+// the most recent first, and without states with duplicate lines.
+func (lh *lineHistory) candidates() []completion.Candidate {
+	comps := make([]completion.Candidate, 0, len(lh.states))
+	printed := make(map[string]bool)
+	pad := len(strconv.Itoa(len(lh.states)))
+	now := time.Now()
+
+	for i := len(lh.states) - 1; i >= 0; i-- {
+		state := lh.states[i]
+		line := lh.lineOf(state)
+
+		if printed[line] {
+			continue
+		}
+
+		printed[line] = true
+
+		display := strings.ReplaceAll(line, "\n", ` `)
+		index := strconv.Itoa(state.seq)
+		display = fmt.Sprintf("%s%s%s %s%s", color.Dim, index, strings.Repeat(" ", pad-len(index)), color.DimReset, display)
+
+		desc := ago(now.Sub(state.time))
+		if state == lh.current {
+			desc = "current"
+		}
+
+		comps = append(comps, completion.Candidate{
+			Display:     display,
+			Value:       line,
+			Description: desc,
+		})
+	}
+
+	return comps
+}
+
+// find returns the most recent state with the given line, if any.
+func (lh *lineHistory) find(line string) *undoState {
+	for i := len(lh.states) - 1; i >= 0; i-- {
+		if lh.lineOf(lh.states[i]) == line {
+			return lh.states[i]
+		}
+	}
+
+	return nil
+}
+
+// diff returns the smallest span of the old line
+// to replace with some text to obtain the new one.
+func diff(old, line string) edit {
+	var prefix, suffix int
+
+	for prefix < len(old) && prefix < len(line) && old[prefix] == line[prefix] {
+		prefix++
+	}
+
+	for suffix < len(old)-prefix && suffix < len(line)-prefix &&
+		old[len(old)-1-suffix] == line[len(line)-1-suffix] {
+		suffix++
+	}
+
+	return edit{
+		start: prefix,
+		end:   len(old) - suffix,
+		text:  line[prefix : len(line)-suffix],
+	}
+}
+
+// apply returns the line with the edit applied to it.
+func (e edit) apply(line string) string {
+	return line[:e.start] + e.text + line[e.end:]
+}
+
+// ago returns a short, human-readable duration.
+func ago(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Minute:
+		return strconv.Itoa(int(elapsed.Seconds())) + "s ago"
+	case elapsed < time.Hour:
+		return strconv.Itoa(int(elapsed.Minutes())) + "m ago"
+	default:
+		return strconv.Itoa(int(elapsed.Hours())) + "h ago"
+	}
 }
 
 // Always returns a non-nil map, whether or not a history source is found.
@@ -209,13 +464,35 @@ func (h *Sources) getHistoryLineChanges() map[int]*lineHistory {
 	return hist
 }
 
+// getLineHistory returns the state changes of the current line, if any.
 func (h *Sources) getLineHistory() *lineHistory {
+	return h.getHistoryLineChanges()[h.linePos()]
+}
+
+// editLineHistory returns the state changes of the current line, creating them
+// if needed: for history lines, the first state is the line in the history source.
+func (h *Sources) editLineHistory() *lineHistory {
 	hist := h.getHistoryLineChanges()
-	if hist == nil {
-		return &lineHistory{}
+	linePos := h.linePos()
+
+	if hist[linePos] == nil {
+		hist[linePos] = &lineHistory{}
+
+		if original, found := h.historyLine(); found {
+			hist[linePos].add(original, len([]rune(original)))
+		}
 	}
 
-	// Compute the position of the current line in the history.
+	if hist[linePos].current == nil {
+		h.save(hist[linePos])
+	}
+
+	return hist[linePos]
+}
+
+// linePos returns the position of the current line in the history,
+// or -1 if the current line is the input buffer.
+func (h *Sources) linePos() int {
 	linePos := -1
 
 	history := h.Current()
@@ -223,12 +500,7 @@ func (h *Sources) getLineHistory() *lineHistory {
 		linePos = history.Len() - h.hpos
 	}
 
-	if hist[linePos] == nil {
-		hist[linePos] = &lineHistory{}
-	}
-
-	// Return the state changes of the current line.
-	return hist[linePos]
+	return linePos
 }
 
 func (h *Sources) restoreLineBuffer() {
@@ -242,13 +514,11 @@ func (h *Sources) restoreLineBuffer() {
 	// Get the undo states for the line buffer
 	// (the last one, not any of the history ones)
 	lh := hist[h.hpos]
-	if lh == nil || len(lh.items) == 0 {
+	if lh == nil || lh.current == nil {
 		return
 	}
 
-	undo := lh.items[len(lh.items)-1]
-
 	// Restore the line to the last known state.
-	h.line.Set([]rune(undo.line)...)
-	h.cursor.Set(undo.pos)
+	h.line.Set([]rune(lh.line)...)
+	h.cursor.Set(lh.current.pos)
 }
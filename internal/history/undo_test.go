@@ -0,0 +1,321 @@
+package history
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/completion"
+	"github.com/reeflective/readline/internal/core"
+	"github.com/reeflective/readline/internal/keymap"
+	"github.com/reeflective/readline/internal/ui"
+)
+
+func newTestSources() (*Sources, *core.Line, *core.Cursor) {
+	line := new(core.Line)
+	cursor := core.NewCursor(line)
+	sources := NewSources(line, cursor, new(ui.Hint), inputrc.NewDefaultConfig())
+
+	return sources, line, cursor
+}
+
+// setLine sets the line and saves it as an undo state.
+func setLine(h *Sources, line *core.Line, cursor *core.Cursor, text string) {
+	line.Set([]rune(text)...)
+	cursor.Set(line.Len())
+	h.Save()
+}
+
+func TestSources_UndoTree(t *testing.T) {
+	hist, line, cursor := newTestSources()
+
+	setLine(hist, line, cursor, "")
+	setLine(hist, line, cursor, "a")
+	setLine(hist, line, cursor, "ab")
+	setLine(hist, line, cursor, "abc")
+
+	steps := []struct {
+		name string
+		run  func()
+		want string
+	}{
+		{name: "Undo", run: hist.Undo, want: "ab"},
+		{name: "Undo again", run: hist.Undo, want: "a"},
+		{name: "New branch", run: func() { setLine(hist, line, cursor, "ax") }, want: "ax"},
+		{name: "Redo at branch tip", run: hist.Redo, want: "ax"},
+		{name: "Undo branch", run: hist.Undo, want: "a"},
+		{name: "Redo last branch", run: hist.Redo, want: "ax"},
+		{name: "Earlier", run: hist.UndoEarlier, want: "abc"},
+		{name: "Earlier again", run: hist.UndoEarlier, want: "ab"},
+		{name: "Later", run: hist.UndoLater, want: "abc"},
+		{name: "Undo old branch", run: hist.Undo, want: "ab"},
+		{name: "Redo old branch", run: hist.Redo, want: "abc"},
+		{name: "Later to last", run: hist.UndoLater, want: "ax"},
+		{name: "Later at last", run: hist.UndoLater, want: "ax"},
+		{name: "Revert", run: hist.Revert, want: ""},
+		{name: "Redo after revert", run: hist.Redo, want: "a"},
+	}
+
+	for _, step := range steps {
+		step.run()
+		hist.Reset()
+
+		if got := string(*line); got != step.want {
+			t.Fatalf("%s: line = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestSources_UndoUnsaved(t *testing.T) {
+	hist, line, cursor := newTestSources()
+
+	setLine(hist, line, cursor, "")
+	setLine(hist, line, cursor, "a")
+
+	// Changes not saved yet are kept when undoing.
+	line.Set([]rune("abc")...)
+	hist.Undo()
+	hist.Reset()
+
+	if got := string(*line); got != "a" {
+		t.Fatalf("Undo(): line = %q, want %q", got, "a")
+	}
+
+	if pos := hist.Pos(); pos != 1 {
+		t.Errorf("Pos() = %d, want %d", pos, 1)
+	}
+
+	hist.Redo()
+
+	if got := string(*line); got != "abc" {
+		t.Errorf("Redo(): line = %q, want %q", got, "abc")
+	}
+
+	if pos := hist.Pos(); pos != 0 {
+		t.Errorf("Pos() = %d, want %d", pos, 0)
+	}
+}
+
+func TestCompleteUndo(t *testing.T) {
+	hist, line, cursor := newTestSources()
+
+	setLine(hist, line, cursor, "")
+	setLine(hist, line, cursor, "a")
+	setLine(hist, line, cursor, "ab")
+	setLine(hist, line, cursor, "a")
+	setLine(hist, line, cursor, "ac")
+
+	CompleteUndo(hist)
+
+	var values []string
+	for _, comp := range hist.getLineHistory().candidates() {
+		values = append(values, comp.Value)
+	}
+
+	// Most recent first, without duplicates.
+	want := []string{"ac", "a", "ab", ""}
+	if len(values) != len(want) {
+		t.Fatalf("CompleteUndo() = %q, want %q", values, want)
+	}
+
+	for i := range want {
+		if values[i] != want[i] {
+			t.Fatalf("CompleteUndo() = %q, want %q", values, want)
+		}
+	}
+
+	// Inserting a proposed state goes back to it,
+	// instead of adding a new state to the tree.
+	setLine(hist, line, cursor, "ab")
+	hist.Undo()
+
+	if got := string(*line); got != "a" {
+		t.Errorf("Undo() after completion: line = %q, want %q", got, "a")
+	}
+}
+
+func TestCompleteUndo_engine(t *testing.T) {
+	hist, line, cursor := newTestSources()
+
+	setLine(hist, line, cursor, "")
+	setLine(hist, line, cursor, "a")
+	setLine(hist, line, cursor, "ab")
+	setLine(hist, line, cursor, "a")
+	setLine(hist, line, cursor, "ac")
+
+	keys := new(core.Keys)
+	keymaps, config := keymap.NewEngine(keys, new(core.Iterations))
+
+	engine := completion.NewEngine(new(ui.Hint), keymaps, config)
+	completion.Init(engine, keys, line, cursor, core.NewSelection(line, cursor), nil)
+
+	// States are not filtered with the current line as a prefix.
+	engine.Generate(CompleteUndo(hist))
+
+	if got := engine.Matches(); got != 4 {
+		t.Errorf("Engine.Matches() = %d, want %d", got, 4)
+	}
+
+	if got := string(*line); got != "ac" {
+		t.Errorf("Engine.Generate() line = %q, want %q", got, "ac")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		line string
+		want edit
+	}{
+		{name: "Identical", old: "abc", line: "abc", want: edit{start: 3, end: 3}},
+		{name: "Insert", old: "ac", line: "abc", want: edit{start: 1, end: 1, text: "b"}},
+		{name: "Append", old: "ab", line: "abc", want: edit{start: 2, end: 2, text: "c"}},
+		{name: "Delete", old: "abc", line: "ac", want: edit{start: 1, end: 2}},
+		{name: "Replace", old: "a foo b", line: "a bar b", want: edit{start: 2, end: 5, text: "bar"}},
+		{name: "Repeated", old: "aa", line: "aaa", want: edit{start: 2, end: 2, text: "a"}},
+		{name: "From empty", old: "", line: "abc", want: edit{text: "abc"}},
+		{name: "To empty", old: "abc", line: "", want: edit{end: 3}},
+		{name: "Multibyte", old: "é", line: "è", want: edit{start: 1, end: 2, text: "\xa8"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diff(test.old, test.line)
+			if got != test.want {
+				t.Errorf("diff() = %+v, want %+v", got, test.want)
+			}
+
+			if applied := got.apply(test.old); applied != test.line {
+				t.Errorf("edit.apply() = %q, want %q", applied, test.line)
+			}
+		})
+	}
+}
+
+func TestSources_UndoCheckpoints(t *testing.T) {
+	hist, line, cursor := newTestSources()
+
+	// Enough states for several checkpoints.
+	states := []string{""}
+	setLine(hist, line, cursor, "")
+
+	for i := 1; i < checkpointInterval*3; i++ {
+		text := states[i-1] + strconv.Itoa(i%10)
+		if i%7 == 0 {
+			text = text[1:]
+		}
+
+		states = append(states, text)
+		setLine(hist, line, cursor, text)
+	}
+
+	for i := len(states) - 2; i >= 0; i-- {
+		hist.Undo()
+		hist.Reset()
+
+		if got := string(*line); got != states[i] {
+			t.Fatalf("Undo() to state %d: line = %q, want %q", i, got, states[i])
+		}
+	}
+
+	for i := 1; i < len(states); i++ {
+		hist.Redo()
+		hist.Reset()
+
+		if got := string(*line); got != states[i] {
+			t.Fatalf("Redo() to state %d: line = %q, want %q", i, got, states[i])
+		}
+	}
+}
+
+func TestSources_UndoHistoryLine(t *testing.T) {
+	hist, line, cursor := newTestSources()
+	hist.Current().Write("first")
+	hist.Current().Write("second")
+
+	setLine(hist, line, cursor, "")
+
+	// Unmodified history lines have no undo states.
+	hist.Walk(1)
+	hist.Save()
+	hist.Walk(1)
+	hist.Save()
+
+	if changes := len(hist.getHistoryLineChanges()); changes != 1 {
+		t.Fatalf("got undo states for %d lines, want %d", changes, 1)
+	}
+
+	// Modified ones start with their original line.
+	line.Set([]rune("first line")...)
+	hist.Undo()
+	hist.Reset()
+
+	if got := string(*line); got != "first" {
+		t.Errorf("Undo(): line = %q, want %q", got, "first")
+	}
+
+	// And keep their changes when walking away from them.
+	hist.Redo()
+	hist.Reset()
+	hist.Walk(-1)
+	hist.Walk(1)
+
+	if got := string(*line); got != "first line" {
+		t.Errorf("Walk(): line = %q, want %q", got, "first line")
+	}
+}
+
+// benchmarkUndoLine is a multiline buffer of several kilobytes.
+var benchmarkUndoLine = strings.Repeat("SELECT id, name, created_at FROM users WHERE name LIKE '%foo%';\n", 64)
+
+// BenchmarkSources_Save measures the cost of saving an undo
+// state after each character typed in the middle of a large line.
+func BenchmarkSources_Save(b *testing.B) {
+	hist, line, cursor := newTestSources()
+	setLine(hist, line, cursor, benchmarkUndoLine)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cursor.Set(line.Len() / 2)
+		line.Insert(cursor.Pos(), 'x')
+		hist.Save()
+	}
+
+	b.StopTimer()
+	b.ReportMetric(float64(undoSize(hist.getLineHistory()))/float64(b.N), "stored-B/op")
+}
+
+// BenchmarkSources_Undo measures the cost of undoing and redoing states.
+func BenchmarkSources_Undo(b *testing.B) {
+	hist, line, cursor := newTestSources()
+	setLine(hist, line, cursor, benchmarkUndoLine)
+
+	for i := 0; i < checkpointInterval*4; i++ {
+		line.Insert(line.Len()/2, 'x')
+		hist.Save()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		hist.Undo()
+		hist.Reset()
+		hist.Redo()
+		hist.Reset()
+	}
+}
+
+// undoSize returns the number of bytes of lines and edits stored in undo states.
+func undoSize(lh *lineHistory) (size int) {
+	for _, state := range lh.states {
+		size += int(unsafe.Sizeof(*state)) + len(state.line) + len(state.text)
+	}
+
+	return size
+}
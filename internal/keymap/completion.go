@@ -13,6 +13,8 @@ var menuselectKeys = map[string]inputrc.Bind{
 	unescape(`\C-P`):    {Action: "menu-complete-backward"},
 	unescape(`\e[Z`):    {Action: "menu-complete-backward"},
 	unescape(`\C-@`):    {Action: "accept-and-menu-complete"},
+	unescape(`\M- `):    {Action: "menu-toggle-mark"},
+	unescape(`\M-\C-m`): {Action: "menu-accept-marked"},
 	unescape(`\C-F`):    {Action: "menu-incremental-search"},
 	unescape(`\e[A`):    {Action: "menu-complete-backward"},
 	unescape(`\e[B`):    {Action: "menu-complete"},
@@ -20,6 +22,8 @@ var menuselectKeys = map[string]inputrc.Bind{
 	unescape(`\e[D`):    {Action: "menu-complete-backward"},
 	unescape(`\e[1;5A`): {Action: "menu-complete-prev-tag"},
 	unescape(`\e[1;5B`): {Action: "menu-complete-next-tag"},
+	unescape(`\e[5~`):   {Action: "menu-preview-scroll-up"},
+	unescape(`\e[6~`):   {Action: "menu-preview-scroll-down"},
 }
 
 // isearchCommands is a subset of commands that are valid in incremental-search mode.
@@ -70,6 +74,26 @@ var nonIsearchCommands = []string{
 	"self-insert",
 }
 
+// exCommandLineCommands are the commands valid in the Vim ex command line,
+// in addition to those valid in non-incremental search mode.
+var exCommandLineCommands = append([]string{
+	"vi-movement-mode",
+	"complete",
+	"menu-complete",
+	"expand-or-complete",
+	"previous-history",
+	"next-history",
+	"up-history",
+	"down-history",
+	"up-line-or-history",
+	"down-line-or-history",
+	"up-line-or-search",
+	"down-line-or-search",
+	"history-search-backward",
+	"history-search-forward",
+	"menu-select",
+}, nonIsearchCommands...)
+
 // getContextBinds is in charge of returning the precise list of binds
 // that are relevant in a given context (local/main keymap). Some submodes
 // (like non/incremental search) will further restrict the set of binds.
@@ -91,6 +115,8 @@ func (m *Engine) getContextBinds(main bool) (binds map[string]inputrc.Bind) {
 	switch {
 	case m.Local() == Isearch:
 		binds = m.restrictCommands(m.main, isearchCommands)
+	case m.exCommand:
+		binds = m.restrictCommands(m.main, exCommandLineCommands)
 	case m.nonIncSearch:
 		binds = m.restrictCommands(m.main, nonIsearchCommands)
 	}
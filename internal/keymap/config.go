@@ -13,19 +13,28 @@ import (
 // readline global options specific to this library.
 var readlineOptions = map[string]interface{}{
 	// General edition
-	"autopairs": false,
+	"autopairs":                  false,
+	"abbreviations-anywhere":     false,
+	"abbreviation-cursor-marker": "%|",
+	"keyword-matchers":           "",
 
 	// Completion
-	"autocomplete":               false,
-	"completion-list-separator":  "--",
-	"completion-selection-style": "\x1b[1;30m",
+	"autocomplete":                  false,
+	"completion-list-separator":     "--",
+	"completion-selection-style":    "\x1b[1;30m",
+	"completion-marked-style":       "\x1b[1;4m",
+	"completion-smart-case":         false,
+	"completion-approximate-errors": 0,
+	"correct-max-errors":            2,
 
 	// Prompt & General UI
 	"transient-prompt":          false,
 	"usage-hint-always":         false,
 	"history-autosuggest":       false,
+	"history-expand-on-accept":  false,
 	"multiline-column":          true,
 	"multiline-column-numbered": false,
+	"multiline-syntax":          false,
 }
 
 // ReloadConfig parses all valid .inputrc configurations and immediately
@@ -77,7 +77,10 @@ func MatchMain(eng *Engine) (bind inputrc.Bind, command func(), prefix bool) {
 
 	// Non-incremental search mode should always insert the keys
 	// if they did not exactly match one of the valid commands.
-	if eng.nonIncSearch && (command == nil || prefix) {
+	// The escape key still leaves the ex command line, though.
+	exEscape := eng.exCommand && eng.isEscapeKey()
+
+	if eng.nonIncSearch && (command == nil || prefix) && !exEscape {
 		bind = inputrc.Bind{Action: "self-insert"}
 		eng.active = bind
 		command = eng.resolve(bind)
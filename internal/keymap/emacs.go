@@ -11,6 +11,7 @@ var emacsKeys = map[string]inputrc.Bind{
 	unescape(`\C-N`):     {Action: "down-line-or-history"},
 	unescape(`\C-P`):     {Action: "up-line-or-history"},
 	unescape(`\C-x\C-b`): {Action: "vi-match"},
+	unescape(`\C-x\C-d`): {Action: "add-cursor-next-match"},
 	unescape(`\C-x\C-e`): {Action: "edit-command-line"},
 	unescape(`\C-x\C-n`): {Action: "infer-next-history"},
 	unescape(`\C-x\C-o`): {Action: "overwrite-mode"},
@@ -17,6 +17,7 @@ type Engine struct {
 	skip         bool
 	isCaller     bool
 	nonIncSearch bool
+	exCommand    bool
 
 	keys       *core.Keys
 	iterations *core.Iterations
@@ -209,4 +210,13 @@ func (m *Engine) NonIncrementalSearchStart() {
 // that we stopped editing a non-incremental search minibuffer.
 func (m *Engine) NonIncrementalSearchStop() {
 	m.nonIncSearch = false
+	m.exCommand = false
+}
+
+// ExCommandLineStart notifies the keymap dispatchers that the non-incremental
+// search minibuffer is the Vim ex command line, in which the commands valid in
+// non-incremental search are valid, along with completion and history ones.
+func (m *Engine) ExCommandLineStart() {
+	m.nonIncSearch = true
+	m.exCommand = true
 }
@@ -33,8 +33,11 @@ var vicmdKeys = map[string]inputrc.Bind{
 	unescape(`\C-A`):    {Action: "switch-keyword"},
 	unescape(`\C-L`):    {Action: "clear-screen"},
 	unescape(`\C-M`):    {Action: "accept-line"},
+	unescape(`\C-I`):    {Action: "vi-jump-newer"},
 	unescape(`\C-N`):    {Action: "next-history"},
+	unescape(`\C-O`):    {Action: "vi-jump-older"},
 	unescape(`\C-P`):    {Action: "previous-history"},
+	unescape(`\C-V`):    {Action: "vi-visual-block-mode"},
 	unescape(`\C-X`):    {Action: "switch-keyword"},
 	unescape(`\M-<`):    {Action: "beginning-of-buffer-or-history"},
 	unescape(`\M->`):    {Action: "end-of-buffer-or-history"},
@@ -55,15 +58,24 @@ var vicmdKeys = map[string]inputrc.Bind{
 	unescape("$"):       {Action: "vi-end-of-line"},
 	unescape("%"):       {Action: "vi-match"},
 	unescape("\""):      {Action: "vi-set-buffer"},
+	unescape("#"):       {Action: "vi-search-word-backward"},
+	unescape("*"):       {Action: "vi-search-word-forward"},
 	unescape("0"):       {Action: "beginning-of-line"},
+	unescape(":"):       {Action: "vi-ex-command"},
 	unescape("B"):       {Action: "vi-backward-bigword"},
 	unescape("e"):       {Action: "vi-end-word"},
 	unescape("E"):       {Action: "vi-end-bigword"},
+	unescape("gb"):      {Action: "add-cursor-next-match"},
 	unescape("gg"):      {Action: "beginning-of-buffer-or-history"},
 	unescape("ge"):      {Action: "vi-backward-end-word"},
 	unescape("gE"):      {Action: "vi-backward-end-bigword"},
 	unescape("gu"):      {Action: "vi-down-case"},
 	unescape("gU"):      {Action: "vi-up-case"},
+	unescape("g-"):      {Action: "undo-earlier"},
+	unescape("g+"):      {Action: "undo-later"},
+	unescape("g;"):      {Action: "vi-change-older"},
+	unescape("g,"):      {Action: "vi-change-newer"},
+	unescape("'"):       {Action: "vi-goto-mark-line"},
 	unescape("f"):       {Action: "vi-find-next-char"},
 	unescape("t"):       {Action: "vi-find-next-char-skip"},
 	unescape("i"):       {Action: "vi-insertion-mode"},
@@ -103,11 +115,15 @@ var vioppKeys = map[string]inputrc.Bind{
 	unescape(`\M-`): {Action: "vi-movement-mode"},
 	unescape("a"):   {Action: "vi-select-inside"},
 	unescape("aW"):  {Action: "select-a-blank-word"},
-	unescape("aa"):  {Action: "select-a-shell-word"},
+	unescape("aa"):  {Action: "select-an-argument"},
+	unescape("ae"):  {Action: "select-a-buffer"},
+	unescape("ai"):  {Action: "select-an-indent"},
 	unescape("aw"):  {Action: "select-a-word"},
 	unescape("i"):   {Action: "vi-select-inside"},
 	unescape("iW"):  {Action: "select-in-blank-word"},
-	unescape("ia"):  {Action: "select-in-shell-word"},
+	unescape("ia"):  {Action: "select-in-argument"},
+	unescape("ie"):  {Action: "select-in-buffer"},
+	unescape("ii"):  {Action: "select-in-indent"},
 	unescape("iw"):  {Action: "select-in-word"},
 	unescape("s"):   {Action: "vi-select-surround"},
 	unescape("j"):   {Action: "down-line"},
@@ -116,24 +132,30 @@ var vioppKeys = map[string]inputrc.Bind{
 
 // visualKeys are the default keymaps in Vim Visual mode.
 var visualKeys = map[string]inputrc.Bind{
-	unescape(`\M-`): {Action: "vi-movement-mode"},
-	unescape("aW"):  {Action: "select-a-blank-word"},
-	unescape("aa"):  {Action: "select-a-shell-word"},
-	unescape("aw"):  {Action: "select-a-word"},
-	unescape("iW"):  {Action: "select-in-blank-word"},
-	unescape("ia"):  {Action: "select-in-shell-word"},
-	unescape("iw"):  {Action: "select-in-word"},
-	unescape("a"):   {Action: "vi-select-inside"},
-	unescape("c"):   {Action: "vi-change-to"},
-	unescape("d"):   {Action: "vi-delete-to"},
-	unescape("i"):   {Action: "vi-select-inside"},
-	unescape("j"):   {Action: "next-screen-line"},
-	unescape("k"):   {Action: "previous-screen-line"},
-	unescape("s"):   {Action: "vi-subst"},
-	unescape("S"):   {Action: "vi-add-surround"},
-	unescape("u"):   {Action: "vi-down-case"},
-	unescape("v"):   {Action: "vi-edit-command-line"},
-	unescape("x"):   {Action: "vi-delete-to"},
-	unescape("y"):   {Action: "vi-yank-to"},
-	unescape("~"):   {Action: "vi-swap-case"},
+	unescape(`\M-`):  {Action: "vi-movement-mode"},
+	unescape(`\C-V`): {Action: "vi-visual-block-mode"},
+	unescape(":"):    {Action: "vi-ex-command"},
+	unescape("aW"):   {Action: "select-a-blank-word"},
+	unescape("aa"):   {Action: "select-an-argument"},
+	unescape("ae"):   {Action: "select-a-buffer"},
+	unescape("ai"):   {Action: "select-an-indent"},
+	unescape("aw"):   {Action: "select-a-word"},
+	unescape("iW"):   {Action: "select-in-blank-word"},
+	unescape("ia"):   {Action: "select-in-argument"},
+	unescape("ie"):   {Action: "select-in-buffer"},
+	unescape("ii"):   {Action: "select-in-indent"},
+	unescape("iw"):   {Action: "select-in-word"},
+	unescape("a"):    {Action: "vi-select-inside"},
+	unescape("c"):    {Action: "vi-change-to"},
+	unescape("d"):    {Action: "vi-delete-to"},
+	unescape("i"):    {Action: "vi-select-inside"},
+	unescape("j"):    {Action: "next-screen-line"},
+	unescape("k"):    {Action: "previous-screen-line"},
+	unescape("s"):    {Action: "vi-subst"},
+	unescape("S"):    {Action: "vi-add-surround"},
+	unescape("u"):    {Action: "vi-down-case"},
+	unescape("v"):    {Action: "vi-edit-command-line"},
+	unescape("x"):    {Action: "vi-delete-to"},
+	unescape("y"):    {Action: "vi-yank-to"},
+	unescape("~"):    {Action: "vi-swap-case"},
 }
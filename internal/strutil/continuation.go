@@ -0,0 +1,236 @@
+package strutil
+
+import (
+	"slices"
+	"strings"
+)
+
+// Reasons for which a shell line is syntactically incomplete.
+const (
+	ContinueQuote     = "quote"     // Unterminated single-quoted string.
+	ContinueDquote    = "dquote"    // Unterminated double-quoted string.
+	ContinueBquote    = "bquote"    // Unterminated backquoted command substitution.
+	ContinueCmdsubst  = "cmdsubst"  // Unterminated $(...) command substitution.
+	ContinueBackslash = "backslash" // Trailing backslash escape.
+	ContinueHeredoc   = "heredoc"   // Here-document not terminated by its delimiter.
+	ContinueBracket   = "bracket"   // Unclosed subshell, command group or ${...} expansion.
+)
+
+// commandKeywords are the reserved words after which a command
+// starts, and after which a brace thus opens a command group.
+var commandKeywords = []string{"!", "{", "if", "then", "else", "elif", "while", "until", "do", "time"}
+
+// Continuation returns the reason for which the shell line is syntactically
+// incomplete, and should thus be continued on a new line, or an empty string
+// if the line is complete. When several constructs are unterminated, the most
+// nested one is returned: a quote inside of brackets yields ContinueQuote.
+//
+// Only grouping brackets are matched: parenthesis and braces starting a command
+// (subshells and command groups), and $(...) and ${...} expansions. Brackets in
+// the middle of words (like globs or brace expansions) and '[' are ignored.
+func Continuation(line string) string {
+	var (
+		runes    = []rune(line)
+		brackets []rune
+		heredocs []heredoc
+		offset   int
+		command  = true // The next word starts a command.
+		target   bool   // The next word is the target of a redirection.
+	)
+
+	for offset <= len(runes) {
+		tokens := Lex(string(runes[offset:]))
+		next := -1
+
+		for i, tok := range tokens {
+			if tok.Incomplete {
+				return incompleteReason(tok)
+			}
+
+			switch tok.Kind {
+			case TokenRedirection:
+				if doc, found := readHeredoc(tok, tokens[i+1:]); found {
+					heredocs = append(heredocs, doc)
+				}
+
+				target = true
+
+			case TokenOperator:
+				switch {
+				case tok.Text == "(" && command:
+					brackets = append(brackets, '(')
+				case tok.Text == ")":
+					brackets = closeBracket(brackets, '(')
+				}
+
+				command, target = true, false
+
+				// Here-document bodies start on the next line,
+				// and must not be lexed as shell input.
+				if tok.Text == "\n" && len(heredocs) > 0 {
+					end, complete := skipHeredocs(runes, offset+tok.Runes.End, heredocs)
+					if !complete {
+						return ContinueHeredoc
+					}
+
+					heredocs, next = nil, end
+				}
+
+			case TokenComment:
+
+			default:
+				// Only whole words are reserved words.
+				if tok.Joined {
+					break
+				}
+
+				if target {
+					target = false
+					break
+				}
+
+				if !command {
+					break
+				}
+
+				word := wordText(tokens[i:])
+
+				switch word {
+				case "{":
+					brackets = append(brackets, '{')
+				case "}":
+					brackets = closeBracket(brackets, '{')
+				}
+
+				command = slices.Contains(commandKeywords, word)
+			}
+
+			if next >= 0 {
+				break
+			}
+		}
+
+		if next < 0 {
+			break
+		}
+
+		offset = next
+	}
+
+	switch {
+	case len(heredocs) > 0:
+		return ContinueHeredoc
+	case len(brackets) > 0:
+		return ContinueBracket
+	default:
+		return ""
+	}
+}
+
+// heredoc is a here-document delimiter, waiting for its body.
+type heredoc struct {
+	delim    string
+	trimTabs bool
+}
+
+// incompleteReason returns the continuation reason for an incomplete token.
+func incompleteReason(tok Token) string {
+	switch {
+	case tok.Kind == TokenQuoted && tok.Quote == '\'':
+		return ContinueQuote
+	case tok.Kind == TokenQuoted:
+		return ContinueDquote
+	case tok.Kind == TokenSubshell && strings.HasPrefix(tok.Text, "`"):
+		return ContinueBquote
+	case tok.Kind == TokenSubshell:
+		return ContinueCmdsubst
+	case tok.Kind == TokenWord:
+		return ContinueBackslash
+	default:
+		return ContinueBracket
+	}
+}
+
+// readHeredoc returns the here-document started by a redirection token, if it
+// is one, with its delimiter made of the (unquoted) word tokens following it.
+func readHeredoc(redir Token, next []Token) (doc heredoc, found bool) {
+	text := strings.TrimLeft(redir.Text, "0123456789")
+	if text != "<<" && text != "<<-" {
+		return doc, false
+	}
+
+	for i, tok := range next {
+		if !tok.IsWord() || (i > 0 && !tok.Joined) {
+			break
+		}
+
+		switch tok.Kind {
+		case TokenWord, TokenQuoted:
+			doc.delim += tok.Value
+		default:
+			doc.delim += tok.Text
+		}
+	}
+
+	doc.trimTabs = text == "<<-"
+
+	return doc, doc.delim != ""
+}
+
+// skipHeredocs skips the bodies of here-documents starting at the rune position
+// start, and returns the position following the last delimiter line, or false
+// if the line ends before all delimiters are found.
+func skipHeredocs(runes []rune, start int, docs []heredoc) (end int, complete bool) {
+	lines := strings.SplitAfter(string(runes[start:]), "\n")
+	end = start
+
+	for _, doc := range docs {
+		found := false
+
+		for len(lines) > 0 && !found {
+			line := lines[0]
+			lines = lines[1:]
+
+			// The delimiter line must be terminated, but the
+			// very last line of input can be the delimiter.
+			body := strings.TrimSuffix(line, "\n")
+			if doc.trimTabs {
+				body = strings.TrimLeft(body, "\t")
+			}
+
+			found = body == doc.delim
+			end += len([]rune(line))
+		}
+
+		if !found {
+			return end, false
+		}
+	}
+
+	return end, true
+}
+
+// closeBracket pops the opener from the stack, if it is the last one opened.
+func closeBracket(stack []rune, opener rune) []rune {
+	if len(stack) > 0 && stack[len(stack)-1] == opener {
+		return stack[:len(stack)-1]
+	}
+
+	return stack
+}
+
+// wordText returns the text of the shell word starting
+// with the first token, including its joined tokens.
+func wordText(tokens []Token) string {
+	text := tokens[0].Text
+
+	for _, tok := range tokens[1:] {
+		if !tok.Joined || !tok.IsWord() {
+			break
+		}
+
+		text += tok.Text
+	}
+
+	return text
+}
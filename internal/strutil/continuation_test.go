@@ -0,0 +1,58 @@
+package strutil
+
+import "testing"
+
+func TestContinuation(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "Empty", line: "", want: ""},
+		{name: "Simple command", line: "ls -l | grep foo", want: ""},
+		{name: "Single quote", line: "echo 'foo", want: ContinueQuote},
+		{name: "Single quote multiline", line: "echo 'foo\nbar'", want: ""},
+		{name: "Double quote", line: `echo "foo \"bar`, want: ContinueDquote},
+		{name: "Backquote", line: "echo `ls", want: ContinueBquote},
+		{name: "Command substitution", line: "echo $(ls", want: ContinueCmdsubst},
+		{name: "Trailing backslash", line: `echo foo \`, want: ContinueBackslash},
+		{name: "Escaped backslash", line: `echo foo \\`, want: ""},
+		{name: "Line continuation", line: "echo foo \\\nbar", want: ""},
+		{name: "Open parenthesis", line: "(cd /tmp; ls", want: ContinueBracket},
+		{name: "Open brace", line: "if true; then {", want: ContinueBracket},
+		{name: "Closed braces", line: "f() { echo; }", want: ""},
+		{name: "Escaped brace", line: `echo \{`, want: ""},
+		{name: "Quoted brace", line: `echo "{"`, want: ""},
+		{name: "Quote in brackets", line: "(echo 'foo", want: ContinueQuote},
+		{name: "Open parameter expansion", line: "echo ${foo", want: ContinueBracket},
+		{name: "Nested subshells", line: "(cd /tmp; (ls)", want: ContinueBracket},
+		{name: "Function body", line: "f() {", want: ContinueBracket},
+		{name: "Brace group", line: "{ echo foo", want: ContinueBracket},
+		{name: "Brace group closed", line: "{ echo foo; }", want: ""},
+		{name: "Brace argument", line: "{ echo }", want: ContinueBracket},
+		{name: "Square bracket argument", line: "echo [", want: ""},
+		{name: "Test command", line: "[ -z x", want: ""},
+		{name: "Parenthesis in word", line: "echo :(", want: ""},
+		{name: "Brace in word", line: "ls foo{", want: ""},
+		{name: "Brace expansion", line: "echo {a,b", want: ""},
+		{name: "Quoted brace word", line: `{"" echo`, want: ""},
+		{name: "Redirection target", line: "cat < {", want: ""},
+		{name: "Heredoc marker", line: "cat <<EOF", want: ContinueHeredoc},
+		{name: "Heredoc body", line: "cat <<EOF\nfoo", want: ContinueHeredoc},
+		{name: "Heredoc terminated", line: "cat <<EOF\nit's\nEOF", want: ""},
+		{name: "Heredoc quoted delimiter", line: "cat <<'EOF'\nfoo\nEOF\n", want: ""},
+		{name: "Heredoc tabs", line: "cat <<-EOF\nfoo\n\tEOF", want: ""},
+		{name: "Heredoc no tabs", line: "cat <<EOF\nfoo\n\tEOF", want: ContinueHeredoc},
+		{name: "Several heredocs", line: "cat <<A <<B\nA\nfoo", want: ContinueHeredoc},
+		{name: "After heredoc", line: "cat <<A\nA\necho 'foo", want: ContinueQuote},
+		{name: "Here-string", line: "cat <<<foo", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Continuation(test.line); got != test.want {
+				t.Errorf("Continuation(%q) = %q, want %q", test.line, got, test.want)
+			}
+		})
+	}
+}
@@ -15,21 +15,93 @@ const (
 	maxInt = 9223372036854775807
 )
 
-// KeywordSwitcher is a function modifying a given word, returning:
+// KeywordSwitcher is a function modifying the keyword around the cursor position pos in
+// the line, increasing or decreasing it a number of times. It returns:
+// @done     => If true, the handler performed a change.
+// @switched => The updated keyword.
+// @bpos     => Begin position of the keyword in the line.
+// @epos     => End position (excluded) of the keyword in the line.
+type KeywordSwitcher func(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int)
+
+// wordSwitcher is a function modifying a given word, returning:
 // @done     => If true, the handler performed a change.
 // @switched => The updated word.
 // @bpos     => Offset to begin position.
 // @epos     => Offset to end position.
-type KeywordSwitcher func(word string, increase bool, times int) (done bool, switched string, bpos, epos int)
+type wordSwitcher func(word string, increase bool, times int) (done bool, switched string, bpos, epos int)
 
-// KeywordSwitchers returns all keywordSwitchers of the shell.
+// KeywordSwitchers returns all built-in keyword switchers, in the order in which
+// they are tried: the first one performing a change on the keyword wins.
 func KeywordSwitchers() []KeywordSwitcher {
 	return []KeywordSwitcher{
-		switchNumber,
-		switchBoolean,
-		switchWeekday,
-		switchOperator,
+		switchDateTime,
+		switchIPv4,
+		switchIPv6,
+		switchSemver,
+		onWord(switchNumber),
+		onWord(switchBoolean),
+		onWord(cycleWords(weekdays, shortNames(weekdays))),
+		onWord(cycleWords(months, shortNames(months))),
+		CycleSwitcher(httpMethods...),
+		CycleSwitcher(logLevels...),
+		onWord(switchOperator),
+	}
+}
+
+// onWord returns a keyword switcher running a word switcher on the word around the
+// cursor: a series of alphanumeric characters (optionally preceded by a sign), or
+// of punctuation characters. The cursor must be on the part of the word modified.
+func onWord(switcher wordSwitcher) KeywordSwitcher {
+	return func(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int) {
+		if len(line) == 0 {
+			return
+		}
+
+		pos = min(AdjustNumberOperatorPos(pos, line), len(line)-1)
+		bpos, epos = wordAround(line, pos)
+
+		// Include the sign of numbers.
+		if bpos > 0 && (line[bpos-1] == '+' || line[bpos-1] == '-') {
+			bpos--
+		}
+
+		done, switched, wbpos, wepos := switcher(string(line[bpos:epos]), increase, times)
+		epos = bpos + wepos
+		bpos += wbpos
+
+		if !done || pos < bpos || pos >= epos {
+			return false, "", 0, 0
+		}
+
+		return done, switched, bpos, epos
+	}
+}
+
+// wordAround returns the beginning and end (excluded) positions of the series
+// of alphanumeric (or punctuation, or blank) characters around pos.
+func wordAround(line []rune, pos int) (bpos, epos int) {
+	class := func(r rune) int {
+		switch {
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 0
+		case unicode.IsSpace(r):
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	bpos, epos = pos, pos+1
+
+	for bpos > 0 && class(line[bpos-1]) == class(line[pos]) {
+		bpos--
+	}
+
+	for epos < len(line) && class(line[epos]) == class(line[pos]) {
+		epos++
 	}
+
+	return bpos, epos
 }
 
 // AdjustNumberOperatorPos returns an adjusted cursor position when
@@ -74,7 +146,11 @@ func lineSlice(line []rune, cpos, adjust int) (slice string) {
 	return
 }
 
-func switchNumber(word string, _ bool, times int) (done bool, switched string, bpos, epos int) {
+func switchNumber(word string, increase bool, times int) (done bool, switched string, bpos, epos int) {
+	if !increase {
+		times = -times
+	}
+
 	if done, switched, bpos, epos = switchHexa(word, times); done {
 		return
 	}
@@ -322,10 +398,6 @@ func switchBoolean(word string, _ bool, _ int) (done bool, switched string, bpos
 	return done, switched, bpos, epos
 }
 
-func switchWeekday(word string, inc bool, _ int) (done bool, switched string, bpos, epos int) {
-	return
-}
-
 func switchOperator(word string, _ bool, _ int) (done bool, switched string, bpos, epos int) {
 	epos = len(word)
 
@@ -0,0 +1,107 @@
+package strutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// switchKeyword runs the keyword switchers on the line, at the position of
+// its first | (which is removed), and returns the line after the first change.
+func switchKeyword(switchers []KeywordSwitcher, line string, increase bool, times int) string {
+	pos := len([]rune(line[:strings.Index(line, "|")]))
+	runes := []rune(strings.Replace(line, "|", "", 1))
+
+	for _, switcher := range switchers {
+		done, switched, bpos, epos := switcher(runes, pos, increase, times)
+		if done {
+			return string(runes[:bpos]) + switched + string(runes[epos:])
+		}
+	}
+
+	return string(runes)
+}
+
+func TestKeywordSwitchers(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		decrease bool
+		times    int
+		want     string
+	}{
+		// Numbers and words
+		{name: "Decimal", line: "sleep |9", want: "sleep 10"},
+		{name: "Decimal decrease", line: "head -n |1", decrease: true, want: "head -n 0"},
+		{name: "Negative decimal", line: "x=-|5", decrease: true, times: 2, want: "x=-7"},
+		{name: "Hexadecimal", line: "0x|0f", want: "0x10"},
+		{name: "Boolean", line: "--color=|true", want: "--color=false"},
+		{name: "Weekday", line: "on Su|nday", want: "on Monday"},
+		{name: "Short weekday", line: "FR|I", times: 2, want: "SUN"},
+		{name: "Month", line: "in |december", want: "in january"},
+		{name: "Short month", line: "Ma|r", decrease: true, want: "Feb"},
+		{name: "HTTP method", line: "curl -X |GET", want: "curl -X POST"},
+		{name: "HTTP method decrease", line: "-X |get", decrease: true, want: "-X options"},
+		{name: "Log level", line: "--log-level=|info", times: 2, want: "--log-level=error"},
+		{name: "Operator", line: "a |&& b", want: "a || b"},
+
+		// Dates and times
+		{name: "Day", line: "2024-02-2|8", want: "2024-02-29"},
+		{name: "Day carry", line: "2023-12-3|1", want: "2024-01-01"},
+		{name: "Month clamps day", line: "2024-0|1-31", want: "2024-02-29"},
+		{name: "Year", line: "|2024-02-29", want: "2025-02-28"},
+		{name: "Minute", line: "2024-12-31T23:5|9:00Z", want: "2025-01-01T00:00:00Z"},
+		{name: "Hour decrease", line: "2024-01-01 |00:30", decrease: true, want: "2023-12-31 23:30"},
+		{name: "Time", line: "23:59:5|9", want: "00:00:00"},
+		{name: "Time zone", line: "12:0|0+02:00", want: "12:01+02:00"},
+
+		// Versions
+		{name: "Semver patch", line: "v1.2.|9", want: "v1.2.10"},
+		{name: "Semver minor", line: "pkg@v1.|2.3", want: "pkg@v1.3.0"},
+		{name: "Semver major", line: "|1.2.3-rc.1", times: 2, want: "3.0.0-rc.1"},
+		{name: "Semver decrease", line: "1.|0.5", decrease: true, want: "1.0.5"},
+
+		// Addresses
+		{name: "IPv4", line: "ping 10.0.0.2|55", want: "ping 10.0.1.0"},
+		{name: "IPv4 octet", line: "1|92.168.1.1", decrease: true, want: "191.168.1.1"},
+		{name: "IPv4 prefix", line: "10.0.0.0/|8", want: "10.0.0.0/9"},
+		{name: "IPv4 wraps", line: "255.255.255.25|5", want: "0.0.0.0"},
+		{name: "IPv6", line: "2001:d|b8::1", want: "2001:db9::1"},
+		{name: "IPv6 last group", line: "fe80::fff|f", want: "fe80::1:0"},
+		{name: "IPv6 elided", line: "fe80:|:1", want: "fe80::1:1"},
+		{name: "IPv6 uppercase", line: "FE80::|A", want: "FE80::B"},
+		{name: "IPv6 trailing elision at end of line", line: "ping fe80::|", want: "ping fe80::1"},
+		{name: "IPv6 unspecified at end of line", line: "ping ::|", want: "ping ::1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			times := max(test.times, 1)
+
+			got := switchKeyword(KeywordSwitchers(), test.line, !test.decrease, times)
+			if got != test.want {
+				t.Errorf("KeywordSwitchers() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCycleSwitcher(t *testing.T) {
+	switchers := []KeywordSwitcher{CycleSwitcher("dev", "staging", "prod")}
+
+	tests := []struct {
+		line     string
+		decrease bool
+		want     string
+	}{
+		{line: "--env=|dev", want: "--env=staging"},
+		{line: "--env=Pr|od", want: "--env=Dev"},
+		{line: "--env=|DEV", decrease: true, want: "--env=PROD"},
+		{line: "--env=|test", want: "--env=test"},
+	}
+
+	for _, test := range tests {
+		if got := switchKeyword(switchers, test.line, !test.decrease, 1); got != test.want {
+			t.Errorf("CycleSwitcher() on %q = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,494 @@
+package strutil
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind is the type of a shell token.
+type TokenKind int
+
+// Token kinds.
+const (
+	TokenWord        TokenKind = iota // An unquoted word, possibly with backslash escapes.
+	TokenQuoted                       // A single or double-quoted string, quotes included.
+	TokenVariable                     // A parameter expansion ($var, ${var}, $1, $?...).
+	TokenSubshell                     // A command substitution, $(...) or `...`.
+	TokenOperator                     // A control operator (|, ||, |&, &, &&, ;, ;;, (, ), newline).
+	TokenRedirection                  // A redirection operator, with its optional file descriptor.
+	TokenComment                      // A comment, up to the end of the line.
+)
+
+var tokenKinds = map[TokenKind]string{
+	TokenWord:        "word",
+	TokenQuoted:      "quoted",
+	TokenVariable:    "variable",
+	TokenSubshell:    "subshell",
+	TokenOperator:    "operator",
+	TokenRedirection: "redirection",
+	TokenComment:     "comment",
+}
+
+func (k TokenKind) String() string {
+	return tokenKinds[k]
+}
+
+// Span is a range of positions [Start, End) in a string.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Token is a lexical token of a shell line.
+type Token struct {
+	Kind       TokenKind
+	Text       string // The token text, as found in the input.
+	Value      string // The unquoted and unescaped text of words and quoted strings.
+	Runes      Span   // The position of the token in the input, in runes.
+	Bytes      Span   // The position of the token in the input, in bytes.
+	Quote      rune   // The quote character of quoted strings.
+	Joined     bool   // Not separated from the previous token by blanks: both are part of the same word.
+	Incomplete bool   // Unterminated quote, escape, expansion or command substitution.
+}
+
+// IsWord returns true if the token is part of a shell word (that is,
+// a word, a quoted string, a parameter expansion or a substitution).
+func (t Token) IsWord() bool {
+	return t.Kind <= TokenSubshell
+}
+
+// Lex splits a shell line into tokens, following /bin/sh lexical rules. It never fails:
+// unterminated quotes, escapes and substitutions are reported as incomplete tokens. Like
+// Split, it does not perform any expansion.
+func Lex(input string) []Token {
+	lex := &lexer{runes: []rune(input)}
+
+	// Byte offsets of all runes, for byte spans.
+	lex.offsets = make([]int, 0, len(lex.runes)+1)
+	for offset := range input {
+		lex.offsets = append(lex.offsets, offset)
+	}
+
+	lex.offsets = append(lex.offsets, len(input))
+
+	lex.run()
+
+	return lex.tokens
+}
+
+// Incomplete returns true if the last token of the list is incomplete.
+func Incomplete(tokens []Token) bool {
+	return len(tokens) > 0 && tokens[len(tokens)-1].Incomplete
+}
+
+// WordAt returns the indexes [first, last] of the tokens forming the shell word
+// which contains (or ends at) the rune position pos. If there is no word at this
+// position (for instance, just after a blank), found is false.
+func WordAt(tokens []Token, pos int) (first, last int, found bool) {
+	for i, tok := range tokens {
+		if !tok.IsWord() || pos < tok.Runes.Start || pos > tok.Runes.End {
+			continue
+		}
+
+		first, last = i, i
+
+		for first > 0 && tokens[first].Joined && tokens[first-1].IsWord() {
+			first--
+		}
+
+		for last < len(tokens)-1 && tokens[last+1].Joined && tokens[last+1].IsWord() {
+			last++
+		}
+
+		return first, last, true
+	}
+
+	return 0, 0, false
+}
+
+type lexer struct {
+	runes   []rune
+	offsets []int
+	pos     int
+	tokens  []Token
+	joined  bool
+}
+
+func (l *lexer) run() {
+	for l.pos < len(l.runes) {
+		start := l.pos
+		char := l.runes[l.pos]
+
+		switch {
+		case char == ' ' || char == '\t':
+			l.pos++
+			l.joined = false
+
+			continue
+
+		case char == '\\' && l.peek(1) == '\n':
+			// Line continuation.
+			l.pos += 2
+			l.joined = false
+
+			continue
+
+		case char == '#' && !l.joined:
+			for l.pos < len(l.runes) && l.runes[l.pos] != '\n' {
+				l.pos++
+			}
+
+			l.emit(TokenComment, start, "", false)
+
+		case l.isRedirection():
+			l.lexRedirection()
+			l.emit(TokenRedirection, start, "", false)
+
+		case strings.ContainsRune("|&;()\n", char):
+			l.lexOperator()
+			l.emit(TokenOperator, start, "", false)
+
+		case char == '\'':
+			l.lexSingleQuoted()
+
+		case char == '"':
+			l.lexDoubleQuoted()
+
+		case char == '$' && l.peek(1) == '(':
+			l.pos += 2
+			complete := l.skipParens()
+			l.emit(TokenSubshell, start, "", !complete)
+
+		case char == '`':
+			l.pos++
+			complete := l.skipBackquotes()
+			l.emit(TokenSubshell, start, "", !complete)
+
+		case char == '$' && l.lexVariable():
+
+		default:
+			l.lexWord()
+		}
+	}
+}
+
+// emit adds a token spanning from start to the current position.
+func (l *lexer) emit(kind TokenKind, start int, value string, incomplete bool) {
+	tok := Token{
+		Kind:       kind,
+		Text:       string(l.runes[start:l.pos]),
+		Value:      value,
+		Runes:      Span{Start: start, End: l.pos},
+		Bytes:      Span{Start: l.offsets[start], End: l.offsets[l.pos]},
+		Joined:     l.joined && kind <= TokenSubshell,
+		Incomplete: incomplete,
+	}
+
+	if kind == TokenQuoted {
+		tok.Quote = l.runes[start]
+	}
+
+	l.tokens = append(l.tokens, tok)
+
+	// Operators, redirections and comments are never part of words.
+	l.joined = tok.IsWord()
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.runes) {
+		return utf8.RuneError
+	}
+
+	return l.runes[l.pos+offset]
+}
+
+// isRedirection returns true if a redirection operator starts at the current position,
+// including when preceded by a file descriptor number at the beginning of a word.
+func (l *lexer) isRedirection() bool {
+	pos := l.pos
+
+	if !l.joined {
+		for pos < len(l.runes) && l.runes[pos] >= '0' && l.runes[pos] <= '9' {
+			pos++
+		}
+	}
+
+	if pos >= len(l.runes) {
+		return false
+	}
+
+	switch l.runes[pos] {
+	case '<', '>':
+		return true
+	case '&':
+		return pos == l.pos && pos+1 < len(l.runes) && l.runes[pos+1] == '>'
+	default:
+		return false
+	}
+}
+
+func (l *lexer) lexRedirection() {
+	for l.runes[l.pos] >= '0' && l.runes[l.pos] <= '9' {
+		l.pos++
+	}
+
+	for _, op := range []string{"&>>", "&>", "<<<", "<<-", "<<", "<>", "<&", ">>", ">&", ">|", "<", ">"} {
+		if strings.HasPrefix(string(l.runes[l.pos:min(l.pos+len(op), len(l.runes))]), op) {
+			l.pos += len(op)
+			return
+		}
+	}
+}
+
+func (l *lexer) lexOperator() {
+	for _, op := range []string{"||", "|&", "&&", ";;", "|", "&", ";", "(", ")", "\n"} {
+		if strings.HasPrefix(string(l.runes[l.pos:min(l.pos+len(op), len(l.runes))]), op) {
+			l.pos += len(op)
+			return
+		}
+	}
+}
+
+func (l *lexer) lexSingleQuoted() {
+	start := l.pos
+	l.pos++
+
+	for l.pos < len(l.runes) {
+		if l.runes[l.pos] == '\'' {
+			l.pos++
+			l.emit(TokenQuoted, start, string(l.runes[start+1:l.pos-1]), false)
+
+			return
+		}
+
+		l.pos++
+	}
+
+	l.emit(TokenQuoted, start, string(l.runes[start+1:]), true)
+}
+
+func (l *lexer) lexDoubleQuoted() {
+	start := l.pos
+	l.pos++
+
+	var value strings.Builder
+
+	for l.pos < len(l.runes) {
+		char := l.runes[l.pos]
+
+		switch {
+		case char == '"':
+			l.pos++
+			l.emit(TokenQuoted, start, value.String(), false)
+
+			return
+
+		case char == '\\':
+			next := l.peek(1)
+			if next == utf8.RuneError {
+				l.pos++
+				l.emit(TokenQuoted, start, value.String(), true)
+
+				return
+			}
+
+			// Only some characters can be escaped in double quotes.
+			if strings.ContainsRune(doubleEscapeChars, next) {
+				if next != '\n' {
+					value.WriteRune(next)
+				}
+			} else {
+				value.WriteRune(char)
+				value.WriteRune(next)
+			}
+
+			l.pos += 2
+
+		case char == '$' && l.peek(1) == '(':
+			subStart := l.pos
+			l.pos += 2
+
+			if !l.skipParens() {
+				value.WriteString(string(l.runes[subStart:]))
+				l.emit(TokenQuoted, start, value.String(), true)
+
+				return
+			}
+
+			value.WriteString(string(l.runes[subStart:l.pos]))
+
+		case char == '`':
+			subStart := l.pos
+			l.pos++
+
+			if !l.skipBackquotes() {
+				value.WriteString(string(l.runes[subStart:]))
+				l.emit(TokenQuoted, start, value.String(), true)
+
+				return
+			}
+
+			value.WriteString(string(l.runes[subStart:l.pos]))
+
+		default:
+			value.WriteRune(char)
+			l.pos++
+		}
+	}
+
+	l.emit(TokenQuoted, start, value.String(), true)
+}
+
+// lexVariable lexes a parameter expansion, and returns false if
+// the dollar sign is not followed by a valid parameter name.
+func (l *lexer) lexVariable() bool {
+	start := l.pos
+	next := l.peek(1)
+
+	switch {
+	case next == '{':
+		l.pos += 2
+		for l.pos < len(l.runes) && l.runes[l.pos] != '}' {
+			l.pos++
+		}
+
+		if l.pos == len(l.runes) {
+			l.emit(TokenVariable, start, "", true)
+			return true
+		}
+
+		l.pos++
+
+	case IsNameStart(next):
+		l.pos += 2
+		for l.pos < len(l.runes) && IsNameChar(l.runes[l.pos]) {
+			l.pos++
+		}
+
+	case next != utf8.RuneError && strings.ContainsRune("0123456789?!#$*@-", next):
+		l.pos += 2
+
+	default:
+		return false
+	}
+
+	l.emit(TokenVariable, start, "", false)
+
+	return true
+}
+
+func (l *lexer) lexWord() {
+	start := l.pos
+
+	var value strings.Builder
+
+	for l.pos < len(l.runes) {
+		char := l.runes[l.pos]
+
+		if char == '\\' {
+			next := l.peek(1)
+
+			switch next {
+			case utf8.RuneError:
+				l.pos++
+				l.emit(TokenWord, start, value.String(), true)
+
+				return
+			case '\n':
+				l.pos += 2
+
+				continue
+			}
+
+			value.WriteRune(next)
+			l.pos += 2
+
+			continue
+		}
+
+		if strings.ContainsRune(" \t\n|&;()<>'\"`", char) {
+			break
+		}
+
+		// A dollar sign only ends a word when starting a parameter expansion.
+		if char == '$' && l.pos > start && (l.peek(1) == '(' || l.peek(1) == '{' || IsNameStart(l.peek(1))) {
+			break
+		}
+
+		value.WriteRune(char)
+		l.pos++
+	}
+
+	l.emit(TokenWord, start, value.String(), false)
+}
+
+// skipParens moves past the parenthesis closing an already opened one,
+// skipping over nested parenthesis, quotes and escapes.
+// Returns false if the input ends before.
+func (l *lexer) skipParens() bool {
+	depth := 1
+
+	for l.pos < len(l.runes) {
+		switch l.runes[l.pos] {
+		case '\\':
+			l.pos++
+		case '\'':
+			l.pos++
+			for l.pos < len(l.runes) && l.runes[l.pos] != '\'' {
+				l.pos++
+			}
+		case '"':
+			l.pos++
+			for l.pos < len(l.runes) && l.runes[l.pos] != '"' {
+				if l.runes[l.pos] == '\\' {
+					l.pos++
+				}
+				l.pos++
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return true
+			}
+		}
+
+		l.pos++
+	}
+
+	l.pos = len(l.runes)
+
+	return false
+}
+
+// skipBackquotes moves past the next unescaped backquote.
+// Returns false if the input ends before.
+func (l *lexer) skipBackquotes() bool {
+	for l.pos < len(l.runes) {
+		switch l.runes[l.pos] {
+		case '\\':
+			l.pos++
+		case '`':
+			l.pos++
+			return true
+		}
+
+		l.pos++
+	}
+
+	l.pos = len(l.runes)
+
+	return false
+}
+
+// IsNameStart returns true if the rune can start a shell variable name.
+func IsNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// IsNameChar returns true if the rune can be part of a shell variable name.
+func IsNameChar(r rune) bool {
+	return IsNameStart(r) || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,160 @@
+package strutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	type tok struct {
+		Kind       TokenKind
+		Text       string
+		Value      string
+		Joined     bool
+		Incomplete bool
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []tok
+	}{
+		{
+			name:  "Words and operators",
+			input: "ls -l | grep foo&&echo",
+			want: []tok{
+				{TokenWord, "ls", "ls", false, false},
+				{TokenWord, "-l", "-l", false, false},
+				{TokenOperator, "|", "", false, false},
+				{TokenWord, "grep", "grep", false, false},
+				{TokenWord, "foo", "foo", false, false},
+				{TokenOperator, "&&", "", false, false},
+				{TokenWord, "echo", "echo", false, false},
+			},
+		},
+		{
+			name:  "Quotes and escapes",
+			input: `cat "my file" 'it''s' a\ b`,
+			want: []tok{
+				{TokenWord, "cat", "cat", false, false},
+				{TokenQuoted, `"my file"`, "my file", false, false},
+				{TokenQuoted, `'it'`, "it", false, false},
+				{TokenQuoted, `'s'`, "s", true, false},
+				{TokenWord, `a\ b`, "a b", false, false},
+			},
+		},
+		{
+			name:  "Redirections",
+			input: "cmd 2>&1 >>out <in &>all",
+			want: []tok{
+				{TokenWord, "cmd", "cmd", false, false},
+				{TokenRedirection, "2>&", "", false, false},
+				{TokenWord, "1", "1", false, false},
+				{TokenRedirection, ">>", "", false, false},
+				{TokenWord, "out", "out", false, false},
+				{TokenRedirection, "<", "", false, false},
+				{TokenWord, "in", "in", false, false},
+				{TokenRedirection, "&>", "", false, false},
+				{TokenWord, "all", "all", false, false},
+			},
+		},
+		{
+			name:  "Variables and substitutions",
+			input: "echo $HOME/x ${PATH} $(ls \"a)\") `id`",
+			want: []tok{
+				{TokenWord, "echo", "echo", false, false},
+				{TokenVariable, "$HOME", "", false, false},
+				{TokenWord, "/x", "/x", true, false},
+				{TokenVariable, "${PATH}", "", false, false},
+				{TokenSubshell, `$(ls "a)")`, "", false, false},
+				{TokenSubshell, "`id`", "", false, false},
+			},
+		},
+		{
+			name:  "Comment",
+			input: "echo a#b # comment",
+			want: []tok{
+				{TokenWord, "echo", "echo", false, false},
+				{TokenWord, "a#b", "a#b", false, false},
+				{TokenComment, "# comment", "", false, false},
+			},
+		},
+		{
+			name:  "Unterminated double quote",
+			input: `echo "foo \"bar`,
+			want: []tok{
+				{TokenWord, "echo", "echo", false, false},
+				{TokenQuoted, `"foo \"bar`, `foo "bar`, false, true},
+			},
+		},
+		{
+			name:  "Unterminated escape and substitution",
+			input: `a\ $(b`,
+			want: []tok{
+				{TokenWord, `a\ `, "a ", false, false},
+				{TokenSubshell, "$(b", "", true, true},
+			},
+		},
+		{
+			name:  "Trailing backslash",
+			input: `foo\`,
+			want: []tok{
+				{TokenWord, `foo\`, "foo", false, true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []tok
+
+			for _, token := range Lex(test.input) {
+				got = append(got, tok{token.Kind, token.Text, token.Value, token.Joined, token.Incomplete})
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Lex() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLex_Spans(t *testing.T) {
+	tokens := Lex(`é "ü x"`)
+	if len(tokens) != 2 {
+		t.Fatalf("Lex() returned %d tokens, want 2", len(tokens))
+	}
+
+	if want := (Span{2, 7}); tokens[1].Runes != want {
+		t.Errorf("Token.Runes = %v, want %v", tokens[1].Runes, want)
+	}
+
+	if want := (Span{3, 9}); tokens[1].Bytes != want {
+		t.Errorf("Token.Bytes = %v, want %v", tokens[1].Bytes, want)
+	}
+}
+
+func TestWordAt(t *testing.T) {
+	tokens := Lex(`cd "my dir"/sub | x`)
+
+	tests := []struct {
+		name        string
+		pos         int
+		first, last int
+		found       bool
+	}{
+		{name: "First word", pos: 1, first: 0, last: 0, found: true},
+		{name: "Joined tokens", pos: 5, first: 1, last: 2, found: true},
+		{name: "End of word", pos: 15, first: 1, last: 2, found: true},
+		{name: "After blank", pos: 16, found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, last, found := WordAt(tokens, test.pos)
+			if first != test.first || last != test.last || found != test.found {
+				t.Errorf("WordAt() = %d, %d, %v, want %d, %d, %v", first, last, found, test.first, test.last, test.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package strutil
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// zone matches an optional ISO-8601 time zone designator.
+const zone = `(?:Z|[+-]\d{2}(?::?\d{2})?)?`
+
+var (
+	weekdays    = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	logLevels   = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+	months      = []string{
+		"january", "february", "march", "april", "may", "june",
+		"july", "august", "september", "october", "november", "december",
+	}
+
+	dateTime = regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})(?:([T ])(\d{2}):(\d{2})(?::(\d{2}))?` + zone + `)?|(\d{2}):(\d{2})(?::(\d{2}))?` + zone)
+	semver   = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)((?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`)
+	ipv4     = regexp.MustCompile(`(\d{1,3})\.(\d{1,3})\.(\d{1,3})\.(\d{1,3})(?:/(\d{1,2}))?`)
+	ipv6     = regexp.MustCompile(`[0-9A-Fa-f:]*:[0-9A-Fa-f:]*`)
+)
+
+// CycleSwitcher returns a keyword switcher cycling through a list of words,
+// like GET → POST → PUT, matched regardless of their case: the word replacing
+// the one under the cursor is either lowercase, capitalized or uppercase like it.
+func CycleSwitcher(words ...string) KeywordSwitcher {
+	return onWord(cycleWords(words))
+}
+
+// cycleWords returns a word switcher cycling through the first list of words containing the word.
+func cycleWords(cycles ...[]string) wordSwitcher {
+	return func(word string, increase bool, times int) (done bool, switched string, bpos, epos int) {
+		bpos = len(word) - len(strings.TrimLeft(word, "+-"))
+
+		if !increase {
+			times = -times
+		}
+
+		for _, words := range cycles {
+			for i, candidate := range words {
+				if !strings.EqualFold(word[bpos:], candidate) {
+					continue
+				}
+
+				next := ((i+times)%len(words) + len(words)) % len(words)
+
+				return true, matchCase(word[bpos:], words[next]), bpos, len(word)
+			}
+		}
+
+		return false, "", 0, 0
+	}
+}
+
+// Dates and times (ISO-8601) cases, where the unit under the cursor is modified:
+//
+// 2024-01-31 => 2024-02-29 (on month)
+// 2024-12-31T23:59 => 2025-01-01T00:00 (on minute)
+// 23:59:59 => 00:00:00 (on second).
+func switchDateTime(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int) {
+	match := findAt(dateTime, line, pos)
+	if match == nil {
+		return
+	}
+
+	// Year, month, day, hour, minute and second groups,
+	// depending on the date (and time) or time being matched.
+	groups := []int{1, 2, 3, 5, 6, 7}
+	if match[2] == -1 {
+		groups = []int{-1, -1, -1, 8, 9, 10}
+	}
+
+	values := []int{2000, 1, 1, 0, 0, 0}
+	unit := -1
+
+	for i, group := range groups {
+		if group == -1 || match[group*2] == -1 {
+			continue
+		}
+
+		values[i], _ = strconv.Atoi(string(line[match[group*2]:match[group*2+1]]))
+
+		if unit == -1 || match[group*2] <= pos {
+			unit = i
+		}
+	}
+
+	date := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, time.UTC)
+	if date.Year() != values[0] || int(date.Month()) != values[1] || date.Day() != values[2] ||
+		date.Hour() != values[3] || date.Minute() != values[4] || date.Second() != values[5] {
+		return
+	}
+
+	if !increase {
+		times = -times
+	}
+
+	switch unit {
+	case 0:
+		date = addMonths(date, times*12)
+	case 1:
+		date = addMonths(date, times)
+	case 2:
+		date = date.AddDate(0, 0, times)
+	default:
+		date = date.Add(time.Duration(times) * []time.Duration{time.Hour, time.Minute, time.Second}[unit-3])
+	}
+
+	values = []int{date.Year(), int(date.Month()), date.Day(), date.Hour(), date.Minute(), date.Second()}
+	result := append([]rune{}, line[match[0]:match[1]]...)
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == -1 || match[groups[i]*2] == -1 {
+			continue
+		}
+
+		gbpos, gepos := match[groups[i]*2]-match[0], match[groups[i]*2+1]-match[0]
+		value := []rune(fmt.Sprintf("%0*d", gepos-gbpos, values[i]))
+		result = append(result[:gbpos], append(value, result[gepos:]...)...)
+	}
+
+	switched, bpos, epos = trimUnchanged(line[match[0]:match[1]], result, match[0])
+
+	return true, switched, bpos, epos
+}
+
+// Semantic versions cases, where the component under the cursor is bumped
+// (resetting the following ones), or decreased:
+//
+// v1.2.3 => v1.3.0 (on minor)
+// 1.9.9-rc.1 => 1.9.10-rc.1 (on patch)
+// 2.0.0 => 1.0.0 (decrease on major).
+func switchSemver(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int) {
+	match := findAt(semver, line, pos)
+	if match == nil {
+		return
+	}
+
+	var values [3]int
+
+	unit := 0
+
+	for i := range values {
+		start, end := match[(i+1)*2], match[(i+1)*2+1]
+		values[i], _ = strconv.Atoi(string(line[start:end]))
+
+		if start <= pos {
+			unit = i
+		}
+	}
+
+	if increase {
+		values[unit] += times
+		for i := unit + 1; i < len(values); i++ {
+			values[i] = 0
+		}
+	} else {
+		values[unit] = max(0, values[unit]-times)
+	}
+
+	prefix := string(line[match[0]:match[2]])
+	suffix := string(line[match[8]:match[9]])
+	version := fmt.Sprintf("%s%d.%d.%d%s", prefix, values[0], values[1], values[2], suffix)
+
+	switched, bpos, epos = trimUnchanged(line[match[0]:match[1]], []rune(version), match[0])
+
+	return true, switched, bpos, epos
+}
+
+// IPv4 addresses cases, where the octet (or the prefix length) under the cursor is modified:
+//
+// 192.168.1.255 => 192.168.2.0 (on last octet)
+// 10.0.0.0/8 => 10.0.0.0/9 (on prefix length).
+func switchIPv4(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int) {
+	match := findAt(ipv4, line, pos)
+	if match == nil {
+		return
+	}
+
+	var addr uint32
+
+	octet := 0
+
+	for i := range 4 {
+		start, end := match[(i+1)*2], match[(i+1)*2+1]
+
+		value, _ := strconv.Atoi(string(line[start:end]))
+		if value > 255 {
+			return
+		}
+
+		addr = addr<<8 | uint32(value)
+
+		if start <= pos {
+			octet = i
+		}
+	}
+
+	if !increase {
+		times = -times
+	}
+
+	prefix := ""
+
+	if start := match[10]; start != -1 {
+		length, _ := strconv.Atoi(string(line[start:match[11]]))
+
+		if pos >= start-1 {
+			length = max(0, min(32, length+times))
+		}
+
+		prefix = "/" + strconv.Itoa(length)
+	}
+
+	if match[10] == -1 || pos < match[10]-1 {
+		addr = uint32(int64(addr) + int64(times)<<(8*(3-octet)))
+	}
+
+	result := fmt.Sprintf("%d.%d.%d.%d%s", addr>>24, addr>>16&0xff, addr>>8&0xff, addr&0xff, prefix)
+	switched, bpos, epos = trimUnchanged(line[match[0]:match[1]], []rune(result), match[0])
+
+	return true, switched, bpos, epos
+}
+
+// IPv6 addresses cases, where the group under the cursor is modified:
+//
+// fe80::ffff => fe80::1:0 (on last group)
+// 2001:db8::1 => 2001:db9::1 (on second group).
+func switchIPv6(line []rune, pos int, increase bool, times int) (done bool, switched string, bpos, epos int) {
+	match := findAt(ipv6, line, pos)
+	if match == nil {
+		return
+	}
+
+	text := string(line[match[0]:match[1]])
+
+	addr, err := netip.ParseAddr(text)
+	if err != nil || !addr.Is6() || strings.Contains(text, ".") {
+		return
+	}
+
+	// Find the group under the cursor, taking "::" into account.
+	// The cursor might be at the end of the line, after the address.
+	cursor := min(pos, match[1]-1) - match[0]
+	group := strings.Count(text[:cursor], ":")
+
+	if elided := strings.Index(text, "::"); elided != -1 && cursor >= elided {
+		after := strings.Count(text[elided+2:], ":") + 1
+		if elided+2 == len(text) {
+			after = 0
+		}
+
+		switch {
+		case cursor >= elided+2:
+			group = 8 - after + strings.Count(text[elided+2:cursor], ":")
+		default:
+			group = 7 - after
+		}
+	}
+
+	if !increase {
+		times = -times
+	}
+
+	bytes := addr.As16()
+	step := new(big.Int).Lsh(big.NewInt(int64(times)), uint(16*(7-group)))
+	modulo := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	value := new(big.Int).SetBytes(bytes[:])
+	value.Add(value, step).Mod(value, modulo).FillBytes(bytes[:])
+
+	result := netip.AddrFrom16(bytes).String()
+	if strings.ToUpper(text) == text {
+		result = strings.ToUpper(result)
+	}
+
+	switched, bpos, epos = trimUnchanged(line[match[0]:match[1]], []rune(result), match[0])
+
+	return true, switched, bpos, epos
+}
+
+// findAt returns the rune positions of the match (and submatches) of a pattern which
+// contains pos, and which is not part of a bigger word or number, or nil if none.
+func findAt(pattern *regexp.Regexp, line []rune, pos int) []int {
+	if pos == len(line) && pos > 0 {
+		pos--
+	}
+
+	text := string(line)
+
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		for i, index := range match {
+			if index != -1 {
+				match[i] = utf8.RuneCountInString(text[:index])
+			}
+		}
+
+		if match[0] > pos || match[1] <= pos || !isolated(line, match[0], match[1]) {
+			continue
+		}
+
+		return match
+	}
+
+	return nil
+}
+
+// isolated returns true if the text between bpos and epos
+// is not preceded nor followed by a letter or a number.
+func isolated(line []rune, bpos, epos int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	if bpos > 0 && (isWord(line[bpos-1]) || line[bpos-1] == '.') {
+		return false
+	}
+
+	if epos < len(line) && isWord(line[epos]) {
+		return false
+	}
+
+	return epos+1 >= len(line) || line[epos] != '.' || !unicode.IsDigit(line[epos+1])
+}
+
+// trimUnchanged returns the part of a keyword that has changed,
+// with its beginning and end positions in the line.
+func trimUnchanged(old, keyword []rune, offset int) (switched string, bpos, epos int) {
+	prefix, suffix := 0, 0
+
+	for prefix < len(old) && prefix < len(keyword) && old[prefix] == keyword[prefix] {
+		prefix++
+	}
+
+	for suffix < len(old)-prefix && suffix < len(keyword)-prefix &&
+		old[len(old)-1-suffix] == keyword[len(keyword)-1-suffix] {
+		suffix++
+	}
+
+	if prefix == len(old) && prefix == len(keyword) {
+		return string(keyword), offset, offset + len(old)
+	}
+
+	return string(keyword[prefix : len(keyword)-suffix]), offset + prefix, offset + len(old) - suffix
+}
+
+// addMonths adds months to a date, keeping its day within the resulting month.
+func addMonths(date time.Time, months int) time.Time {
+	month := int(date.Month()) - 1 + months
+	year := date.Year() + month/12
+
+	if month %= 12; month < 0 {
+		month += 12
+		year--
+	}
+
+	lastDay := time.Date(year, time.Month(month+2), 0, 0, 0, 0, 0, time.UTC).Day()
+	day := min(date.Day(), lastDay)
+
+	return time.Date(year, time.Month(month+1), day, date.Hour(), date.Minute(), date.Second(), 0, time.UTC)
+}
+
+// shortNames returns the three-letter abbreviations of names.
+func shortNames(names []string) []string {
+	short := make([]string, len(names))
+
+	for i, name := range names {
+		short[i] = name[:3]
+	}
+
+	return short
+}
+
+// matchCase returns the word with the case of another one:
+// uppercase, capitalized or lowercase.
+func matchCase(model, word string) string {
+	switch {
+	case len(model) > 1 && strings.ToUpper(model) == model:
+		return strings.ToUpper(word)
+	case unicode.IsUpper([]rune(model)[0]):
+		return strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	default:
+		return strings.ToLower(word)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 const (
 	secondaryPromptDefault = "\x1b[1;30m\U00002514 \x1b[0m"
+	secondaryPromptReason  = "\x1b[1;30m%s> \x1b[0m"
 	multilineColumnDefault = "\x1b[1;30m\U00002502 \x1b[0m"
 )
 
@@ -186,12 +187,23 @@ func (p *Prompt) LastUsed() int {
 
 // SecondaryPrint prints the last cursor in secondary prompt mode,
 // which is always activated when the current input line is a multiline one.
+// Without a user-defined prompt and with the multiline-syntax option on, the
+// prompt shows why the line is continued (eg. "quote> "), if it fits before
+// the line.
 func (p *Prompt) SecondaryPrint() {
 	if p.secondaryF != nil {
 		fmt.Print(p.secondaryF())
 		return
 	}
 
+	if p.opts.GetBool("multiline-syntax") {
+		reason := strutil.Continuation(string(*p.line))
+		if reason != "" && len(reason)+2 <= p.primaryCols+1 {
+			fmt.Printf(secondaryPromptReason, reason)
+			return
+		}
+	}
+
 	fmt.Print(secondaryPromptDefault)
 }
 
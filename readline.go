@@ -132,7 +132,11 @@ func (rl *Shell) init() {
 	rl.line.Set()
 	rl.cursor.Set(0)
 	rl.cursor.ResetMark()
+	rl.marks.Reset()
 	rl.selection.Reset()
+	rl.selection.SetSearch(nil)
+	rl.selection.ResetCursors()
+	rl.block = nil
 	rl.Buffers.Reset()
 	rl.History.Reset()
 	rl.Iterations.Reset()
@@ -145,7 +149,7 @@ func (rl *Shell) init() {
 	// Reset/initialize user interface components.
 	rl.Hint.Reset()
 	rl.completer.ResetForce()
-	display.Init(rl.Display, rl.SyntaxHighlighter)
+	display.Init(rl.Display, rl.SyntaxHighlighter, rl.SpanHighlighter)
 }
 
 // run wraps the execution of a target command/sequence with various pre/post actions
@@ -169,10 +173,17 @@ func (rl *Shell) run(main bool, bind inputrc.Bind, command func()) (bool, string
 	// so it knows which line and cursor we should work on.
 	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
 
+	// Some commands work differently in the ex command line.
+	if rl.completer.IsExCommandLine() {
+		if ex, found := rl.exCommands()[bind.Action]; found {
+			command = ex
+		}
+	}
+
 	// The command might be nil, because the provided key sequence
 	// did not match any. We regardless execute everything related
 	// to the command, like any pending ones, and cursor checks.
-	rl.execute(command)
+	rl.execute(bind, command)
 
 	// Either print/clear iterations/active registers hints.
 	rl.updatePosRunHints()
@@ -197,7 +208,12 @@ func (rl *Shell) run(main bool, bind inputrc.Bind, command func()) (bool, string
 
 // Run the dispatched command, any pending operator
 // commands (Vim mode) and some post-run checks.
-func (rl *Shell) execute(command func()) {
+func (rl *Shell) execute(bind inputrc.Bind, command func()) {
+	// Motions in operator-pending mode are not applied to all
+	// cursors, since only the operator changes the line.
+	iterations := *rl.Iterations
+	motion := rl.Keymap.Local() != keymap.ViOpp
+
 	if command != nil {
 		command()
 	}
@@ -208,13 +224,22 @@ func (rl *Shell) execute(command func()) {
 		rl.Keymap.RunPending()
 	}
 
+	// Apply edits and motions to all cursors, if any.
+	rl.updateCursors(bind, iterations, motion)
+
 	// Update/check cursor positions after run.
 	switch rl.Keymap.Main() {
 	case keymap.ViCommand, keymap.ViMove, keymap.Vi:
 		rl.cursor.CheckCommand()
+		rl.selection.CheckBlockEnd()
+		rl.selection.SyncCursors(true)
 	default:
 		rl.cursor.CheckAppend()
+		rl.selection.SyncCursors(false)
 	}
+
+	// Keep marks in sync with any change to the line.
+	rl.marks.Update()
 }
 
 // Some commands show their current status as a hint (iterations/macro).
@@ -11,10 +11,60 @@ import (
 	"github.com/reeflective/readline/internal/history"
 	"github.com/reeflective/readline/internal/keymap"
 	"github.com/reeflective/readline/internal/macro"
+	"github.com/reeflective/readline/internal/strutil"
 	"github.com/reeflective/readline/internal/term"
 	"github.com/reeflective/readline/internal/ui"
 )
 
+// Span is a range of runes [Start, End) of the input line, highlighted with
+// a style (one or more SGR sequences). Spans can overlap: the last one wins.
+type Span = display.Span
+
+// TextObject returns the beginning and end positions (both included) of a text object
+// at (or around) the position pos in the line, like the Vim iw/aw or i(/a( objects.
+// When around is true, the object includes its surroundings (blanks, separators, etc),
+// otherwise only its inner part. If there is no such object, both positions are -1.
+type TextObject = core.TextObject
+
+// KeywordSwitcher modifies the keyword around the cursor position pos in the line, when
+// increasing or decreasing it a number of times (keyword-increase/keyword-decrease).
+// If it does, it returns the new keyword, and the beginning and end (excluded) positions
+// of the text it replaces in the line. Otherwise done is false, and the next one is tried.
+type KeywordSwitcher = strutil.KeywordSwitcher
+
+// CycleSwitcher returns a keyword switcher cycling through a list of words,
+// like GET → POST → PUT, matched regardless of their case: the word replacing
+// the one under the cursor is either lowercase, capitalized or uppercase like it.
+var CycleSwitcher = strutil.CycleSwitcher
+
+// KeywordMatcher is a named regular expression used to select keywords in the blank
+// word under the cursor (select-keyword-next/prev): its whole match is selected first,
+// then each of its capturing subgroups, before trying the next matcher.
+type KeywordMatcher = core.KeywordMatcher
+
+// ValidationError is an error returned by the shell Validator, which may carry the
+// position (in runes) of the error in the input line. Pos is only used when HasPos
+// is true, so that errors without a position don't move the cursor to column 0:
+// use NewValidationError to create an error with a position.
+type ValidationError struct {
+	Err    error
+	Pos    int
+	HasPos bool
+}
+
+// NewValidationError returns a validation error at a position in the input line.
+func NewValidationError(err error, pos int) *ValidationError {
+	return &ValidationError{Err: err, Pos: pos, HasPos: true}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
 // Shell is the main readline shell instance. It contains all the readline state
 // and methods to run the line editor, manage the inputrc configuration, keymaps
 // and commands.
@@ -25,15 +75,18 @@ import (
 // and its components, and how to use them.
 type Shell struct {
 	// Core editor
-	line       *core.Line       // The input line buffer and its management methods.
-	cursor     *core.Cursor     // The cursor and its methods.
-	selection  *core.Selection  // The selection manages various visual/pending selections.
-	Iterations *core.Iterations // Digit arguments for repeating commands.
-	Buffers    *editor.Buffers  // buffers (Vim registers) and methods use/manage/query them.
-	Keys       *core.Keys       // Keys is in charge of reading and managing buffered user input.
-	Keymap     *keymap.Engine   // Manages main/local keymaps, binds, stores command functions, etc.
-	History    *history.Sources // History manages all history types/sources (past commands and undo)
-	Macros     *macro.Engine    // Record, use and display macros.
+	line       *core.Line        // The input line buffer and its management methods.
+	cursor     *core.Cursor      // The cursor and its methods.
+	selection  *core.Selection   // The selection manages various visual/pending selections.
+	marks      *core.Marks       // Vim marks, jumplist and change list, kept in sync with the line.
+	block      *core.BlockInsert // Text inserted in a Vim visual block, replicated on its lines.
+	ex         *editor.Ex        // The Vim ex command line history, completion and substitutions.
+	Iterations *core.Iterations  // Digit arguments for repeating commands.
+	Buffers    *editor.Buffers   // buffers (Vim registers) and methods use/manage/query them.
+	Keys       *core.Keys        // Keys is in charge of reading and managing buffered user input.
+	Keymap     *keymap.Engine    // Manages main/local keymaps, binds, stores command functions, etc.
+	History    *history.Sources  // History manages all history types/sources (past commands and undo)
+	Macros     *macro.Engine     // Record, use and display macros.
 
 	// User interface
 	Config    *inputrc.Config    // Contains all keymaps, binds and per-application settings.
@@ -51,12 +104,60 @@ type Shell struct {
 	// This function should return true if the line is deemed complete (thus asking
 	// the shell to return from its Readline() loop), or false if the shell should
 	// keep reading input on a newline (thus, insert a newline and read).
+	// If nil and the "multiline-syntax" option is on, the line is continued while
+	// it has unclosed quotes, brackets, here-documents or a trailing backslash.
 	AcceptMultiline func(line []rune) (accept bool)
 
+	// Validator is called when a line is about to be accepted (returned to the caller).
+	// If it returns an error, the line is not accepted: the error is displayed below it,
+	// and if the error is (or wraps) a *ValidationError with a position, the cursor is moved to it.
+	Validator func(line []rune) error
+
 	// SyntaxHighlighter is a helper function to provide syntax highlighting.
 	// Once enabled, set to nil to disable again.
 	SyntaxHighlighter func(line []rune) string
 
+	// SpanHighlighter provides syntax highlighting as styled spans of the line,
+	// instead of an escaped string: those are merged with visual selections and
+	// other highlighting by the shell. When set, SyntaxHighlighter is not used.
+	SpanHighlighter func(line []rune) []Span
+
+	// CorrectCommands returns the list of valid commands. When set, and if the first
+	// word of a line about to be accepted is not one of them, the closest command
+	// (within "correct-max-errors" edits) is proposed as a correction: the answer
+	// is one of y (yes), n (no), a (abort: the line is discarded) or e (edit).
+	// CommandSpec.CommandNames can be used to get commands from a spec.
+	CorrectCommands func() []string
+
+	// Abbreviations maps abbreviations to their expansions: when an abbreviation is
+	// typed as a command, it is expanded when inserting a space or accepting the line.
+	// The "abbreviations-anywhere" option expands them in any position, and the first
+	// "abbreviation-cursor-marker" (default "%|") found in an expansion is removed, and
+	// replaced with the cursor.
+	Abbreviations map[string]string
+
+	// TextObjects maps keys to additional Vim text objects, selected in operator pending
+	// and visual modes with i<key> (inner object) or a<key> (outer object), like the
+	// built-in iw/aw or i(/a( objects. Keys already bound with i or a take precedence.
+	TextObjects map[rune]TextObject
+
+	// KeywordSwitchers are used, in order, to increase or decrease the keyword under the
+	// cursor: the first one modifying it wins. It contains the built-in switchers (dates,
+	// versions, addresses, numbers, booleans, names, etc), and custom ones can be inserted
+	// before (to take precedence) or after them.
+	KeywordSwitchers []KeywordSwitcher
+
+	// KeywordMatchers are tried, in order, to select keywords with select-keyword-next/prev.
+	// It contains the built-in matchers (uri, uuid, arn, email, kubernetes and file-line),
+	// and custom ones can be added to it. By default, all of them are used in all keymaps.
+	KeywordMatchers []KeywordMatcher
+
+	// KeywordMatcherSets maps keymaps (emacs, vi-insert, vi-command) to the names of the
+	// keyword matchers used in them, in order. Otherwise the "keyword-matchers" option is
+	// used: ex. set keyword-matchers "uri,email vi-command:kubernetes,uuid" uses the first
+	// list in all keymaps but vi-command.
+	KeywordMatcherSets map[string][]string
+
 	// Completer is a function that produces completions.
 	// It takes the readline line ([]rune) and cursor pos as parameters,
 	// and returns completions with their associated metadata/settings.
@@ -81,8 +182,15 @@ func NewShell(opts ...inputrc.Option) *Shell {
 	shell.line = line
 	shell.cursor = cursor
 	shell.selection = selection
+	shell.marks = core.NewMarks(line)
 	shell.Buffers = editor.NewBuffers()
+	shell.ex = new(editor.Ex)
 	shell.Iterations = iterations
+	shell.Abbreviations = make(map[string]string)
+	shell.TextObjects = make(map[rune]TextObject)
+	shell.KeywordSwitchers = strutil.KeywordSwitchers()
+	shell.KeywordMatchers = core.KeywordMatchers()
+	shell.KeywordMatcherSets = make(map[string][]string)
 
 	// Keymaps and commands
 	keymaps, config := keymap.NewEngine(keys, iterations, opts...)
@@ -0,0 +1,437 @@
+package readline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reeflective/readline/internal/strutil"
+)
+
+// CommandSpec declaratively describes a command tree (commands, subcommands, flags
+// and positional arguments), from which completions, usage hints and flag descriptions
+// are generated. Specs can be built in Go, or loaded from JSON or YAML (see LoadCommandSpec).
+//
+// The root spec stands for the shell itself: its name is ignored, its subcommands
+// are the top-level commands, and its flags are available to all commands.
+type CommandSpec struct {
+	Name        string         `json:"name" yaml:"name"`
+	Aliases     []string       `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Usage       string         `json:"usage,omitempty" yaml:"usage,omitempty"`
+	Flags       []FlagSpec     `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Args        []ArgSpec      `json:"args,omitempty" yaml:"args,omitempty"`
+	Commands    []*CommandSpec `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// FlagSpec describes a command flag. Flags without a value type are boolean
+// flags: other flags take a value, either as the next word or after a '='.
+type FlagSpec struct {
+	Name        string `json:"name" yaml:"name"`                       // Long name, without dashes.
+	Short       string `json:"short,omitempty" yaml:"short,omitempty"` // Short (one letter) name, without dash.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	ValueSpec   `yaml:",inline"`
+}
+
+// ArgSpec describes a positional argument. A variadic argument must
+// be the last one, and is used to complete all remaining arguments.
+type ArgSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Variadic    bool   `json:"variadic,omitempty" yaml:"variadic,omitempty"`
+	ValueSpec   `yaml:",inline"`
+}
+
+// ValueSpec describes the values of a flag or a positional argument.
+// Type is one of: "" or "bool" (no value, for flags only), "string",
+// "int", "path", "file", "dir" or "enum". Enum values are listed in Values.
+// Complete names a callback used to generate values dynamically.
+type ValueSpec struct {
+	Type     string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Complete string   `json:"complete,omitempty" yaml:"complete,omitempty"`
+}
+
+// SpecCallback generates dynamic completions for a flag or argument value.
+// It is given the positional arguments of the current command (without
+// flags) that precede the word being completed, and this word's prefix.
+type SpecCallback func(args []string, prefix string) Completions
+
+// LoadCommandSpec parses a JSON or YAML command tree specification.
+// The specification is parsed as JSON if it starts with a '{', or as YAML otherwise.
+func LoadCommandSpec(data []byte) (*CommandSpec, error) {
+	spec := new(CommandSpec)
+
+	var err error
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, spec)
+	} else {
+		err = yaml.Unmarshal(data, spec)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid command spec: %w", err)
+	}
+
+	return spec, nil
+}
+
+// Completer returns a completer function (to be used as Shell.Completer) generating
+// completions for the command tree. The callbacks map is used to resolve the names
+// of dynamic value completers specified in flags and arguments.
+func (c *CommandSpec) Completer(callbacks map[string]SpecCallback) func(line []rune, cursor int) Completions {
+	return func(line []rune, cursor int) Completions {
+		words, prefix := splitSpecLine(string(line[:cursor]))
+		state := c.walk(words)
+
+		return state.complete(prefix, callbacks)
+	}
+}
+
+// FlagUsage returns the flag names, as displayed in usage strings.
+func (f FlagSpec) FlagUsage() string {
+	var names string
+
+	switch {
+	case f.Short != "" && f.Name != "":
+		names = "-" + f.Short + ", --" + f.Name
+	case f.Short != "":
+		names = "-" + f.Short
+	default:
+		names = "--" + f.Name
+	}
+
+	if f.Type != "" && f.Type != "bool" {
+		names += " <" + f.Type + ">"
+	}
+
+	return names
+}
+
+// CommandUsage returns the usage string of the command: either the
+// one specified, or one generated from its flags and arguments.
+func (c *CommandSpec) CommandUsage() string {
+	if c.Usage != "" {
+		return c.Usage
+	}
+
+	usage := c.Name
+
+	if len(c.Commands) > 0 {
+		usage += " <command>"
+	}
+
+	if len(c.Flags) > 0 {
+		usage += " [flags]"
+	}
+
+	for _, arg := range c.Args {
+		name := "<" + arg.Name + ">"
+		if arg.Variadic {
+			name += "..."
+		}
+
+		usage += " " + name
+	}
+
+	if c.Description != "" {
+		usage += " -- " + c.Description
+	}
+
+	return strings.TrimSpace(usage)
+}
+
+// CommandNames returns the names and aliases of all subcommands of the command.
+// For the root spec, those are all the top-level commands.
+func (c *CommandSpec) CommandNames() []string {
+	var names []string
+
+	for _, sub := range c.Commands {
+		names = append(names, sub.Name)
+		names = append(names, sub.Aliases...)
+	}
+
+	return names
+}
+
+// specState is the result of walking the command tree with the words of a line.
+type specState struct {
+	root    *CommandSpec // The root command (the shell).
+	cmd     *CommandSpec // The deepest command found.
+	globals []FlagSpec   // Flags available to all commands.
+	args    []string     // Positional arguments of the command.
+	pending *FlagSpec    // A flag waiting for its value.
+	noFlags bool         // After a "--", flags are not parsed anymore.
+}
+
+// splitSpecLine splits the line into its complete words, and the
+// prefix of the word being completed (empty if there is none).
+func splitSpecLine(line string) (words []string, prefix string) {
+	words, err := strutil.Split(line)
+	unterminated := err != nil
+
+	// Close any unterminated quote or escape, so that we
+	// can still complete inside quoted or escaped words.
+	for _, closing := range []string{`"`, `'`, ""} {
+		if err == nil {
+			break
+		}
+
+		input := line + closing
+		if closing == "" {
+			input = strings.TrimSuffix(line, `\`)
+		}
+
+		words, err = strutil.Split(input)
+	}
+
+	if len(words) == 0 || err != nil {
+		return nil, ""
+	}
+
+	// If the line ends with a blank outside of
+	// a quoted word, all words are complete.
+	if strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) && !unterminated {
+		return words, ""
+	}
+
+	return words[:len(words)-1], words[len(words)-1]
+}
+
+// walk resolves the command, flags and arguments of a list of complete words.
+func (c *CommandSpec) walk(words []string) *specState {
+	state := &specState{root: c, cmd: c, globals: c.Flags}
+
+	for _, word := range words {
+		switch {
+		case state.pending != nil:
+			state.pending = nil
+
+		case word == "--" && !state.noFlags:
+			state.noFlags = true
+
+		case strings.HasPrefix(word, "-") && len(word) > 1 && !state.noFlags:
+			state.parseFlag(word)
+
+		default:
+			if len(state.args) == 0 {
+				if sub := state.cmd.subcommand(word); sub != nil {
+					state.cmd = sub
+					continue
+				}
+			}
+
+			state.args = append(state.args, word)
+		}
+	}
+
+	return state
+}
+
+// parseFlag looks up the flag(s) in a word, and notes if it expects a value.
+func (s *specState) parseFlag(word string) {
+	if strings.HasPrefix(word, "--") {
+		name, _, inline := strings.Cut(word[2:], "=")
+		if flag := s.flag(name, false); flag != nil && flag.takesValue() && !inline {
+			s.pending = flag
+		}
+
+		return
+	}
+
+	// Stacked short flags: only the last one may take a value.
+	shorts := []rune(word[1:])
+	for i, short := range shorts {
+		flag := s.flag(string(short), true)
+		if flag == nil || !flag.takesValue() {
+			continue
+		}
+
+		if i == len(shorts)-1 {
+			s.pending = flag
+		}
+
+		return
+	}
+}
+
+// flag returns the flag matching the name, either in the current command or in the globals.
+func (s *specState) flag(name string, short bool) *FlagSpec {
+	for _, flags := range [][]FlagSpec{s.cmd.Flags, s.globals} {
+		for i := range flags {
+			if (short && flags[i].Short == name) || (!short && flags[i].Name == name) {
+				return &flags[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// complete generates the completions for the word prefix, in the current state.
+func (s *specState) complete(prefix string, callbacks map[string]SpecCallback) Completions {
+	// Flag values, either after the flag or inlined.
+	if s.pending != nil {
+		return valueUsage(s.pending.values(s.args, prefix, callbacks), s.pending.FlagUsage(), s.pending.Description)
+	}
+
+	if strings.HasPrefix(prefix, "--") && strings.Contains(prefix, "=") && !s.noFlags {
+		name, value, _ := strings.Cut(prefix[2:], "=")
+		if flag := s.flag(name, false); flag != nil {
+			return valueUsage(flag.values(s.args, value, callbacks).Prefix("--"+name+"="), flag.FlagUsage(), flag.Description)
+		}
+
+		return Message("unknown flag: --%s", name)
+	}
+
+	if strings.HasPrefix(prefix, "-") && !s.noFlags {
+		return s.completeFlags().Usage("%s", s.cmd.CommandUsage())
+	}
+
+	comps := Completions{}
+
+	if len(s.args) == 0 {
+		comps = comps.Merge(s.completeCommands())
+	}
+
+	if arg := s.arg(); arg != nil {
+		values := arg.values(s.args, prefix, callbacks)
+		if values.usage == "" && arg.Description != "" {
+			values = values.Usage("%s: %s", arg.Name, arg.Description)
+		}
+
+		comps = comps.Merge(values)
+	}
+
+	if comps.usage == "" {
+		comps = comps.Usage("%s", s.cmd.CommandUsage())
+	}
+
+	return comps
+}
+
+// completeCommands completes the subcommands of the current command.
+func (s *specState) completeCommands() Completions {
+	var vals []string
+
+	for _, sub := range s.cmd.Commands {
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			vals = append(vals, name, sub.Description)
+		}
+	}
+
+	return CompleteValuesDescribed(vals...).Tag("commands")
+}
+
+// completeFlags completes the flags of the current command, and global flags.
+func (s *specState) completeFlags() Completions {
+	comps := Completions{}
+
+	add := func(flags []FlagSpec, tag string) {
+		var vals []string
+
+		for _, flag := range flags {
+			if flag.Name != "" {
+				vals = append(vals, "--"+flag.Name, flag.Description)
+			}
+
+			if flag.Short != "" {
+				vals = append(vals, "-"+flag.Short, flag.Description)
+			}
+		}
+
+		comps = comps.Merge(CompleteValuesDescribed(vals...).Tag(tag))
+	}
+
+	if s.cmd != s.root && len(s.cmd.Flags) > 0 {
+		add(s.cmd.Flags, s.cmd.Name+" flags")
+	}
+
+	add(s.globals, "global flags")
+
+	return comps
+}
+
+// arg returns the positional argument being completed, if any.
+func (s *specState) arg() *ArgSpec {
+	args := s.cmd.Args
+
+	switch {
+	case len(args) == 0:
+		return nil
+	case len(s.args) < len(args):
+		return &args[len(s.args)]
+	case args[len(args)-1].Variadic:
+		return &args[len(args)-1]
+	default:
+		return nil
+	}
+}
+
+// subcommand returns the subcommand matching the name or one of its aliases.
+func (c *CommandSpec) subcommand(name string) *CommandSpec {
+	for _, sub := range c.Commands {
+		if sub.Name == name {
+			return sub
+		}
+
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+
+	return nil
+}
+
+// valueUsage sets the usage of flag or argument values, with its description if any.
+func valueUsage(comps Completions, name, description string) Completions {
+	if description == "" {
+		return comps.Usage("%s", name)
+	}
+
+	return comps.Usage("%s: %s", name, description)
+}
+
+func (f *FlagSpec) takesValue() bool {
+	return f.Type != "" && f.Type != "bool"
+}
+
+// values completes the values of a flag or argument, given its value type.
+func (v ValueSpec) values(args []string, prefix string, callbacks map[string]SpecCallback) Completions {
+	if v.Complete != "" {
+		callback, found := callbacks[v.Complete]
+		if !found {
+			return Message("no completion callback named %q", v.Complete)
+		}
+
+		return callback(args, prefix)
+	}
+
+	switch v.Type {
+	case "path", "file":
+		return CompletePaths(prefix)
+	case "dir":
+		comps := CompletePaths(prefix)
+		dirs := comps.values[:0]
+
+		for _, comp := range comps.values {
+			if strings.HasSuffix(comp.Value, "/") {
+				dirs = append(dirs, comp)
+			}
+		}
+
+		comps.values = dirs
+
+		return comps
+	case "enum":
+		return CompleteValues(v.Values...)
+	default:
+		return Completions{}
+	}
+}
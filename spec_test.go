@@ -0,0 +1,243 @@
+package readline
+
+import (
+	"slices"
+	"testing"
+)
+
+// testSpec returns a command tree used by spec tests.
+func testSpec() *CommandSpec {
+	return &CommandSpec{
+		Flags: []FlagSpec{
+			{Name: "verbose", Short: "v", Description: "verbose output"},
+		},
+		Commands: []*CommandSpec{
+			{
+				Name:        "git",
+				Aliases:     []string{"g"},
+				Description: "version control",
+				Flags: []FlagSpec{
+					{Name: "dir", Short: "C", ValueSpec: ValueSpec{Type: "dir"}},
+				},
+				Commands: []*CommandSpec{
+					{
+						Name: "commit",
+						Flags: []FlagSpec{
+							{Name: "all", Short: "a", ValueSpec: ValueSpec{Type: "bool"}},
+							{Name: "message", Short: "m", Description: "commit message", ValueSpec: ValueSpec{Type: "string"}},
+							{Name: "cleanup", ValueSpec: ValueSpec{Type: "enum", Values: []string{"strip", "verbatim"}}},
+						},
+					},
+					{
+						Name: "push",
+						Args: []ArgSpec{
+							{Name: "remote", Description: "remote name", ValueSpec: ValueSpec{Type: "enum", Values: []string{"origin", "upstream"}}},
+							{Name: "refs", Variadic: true, ValueSpec: ValueSpec{Complete: "refs"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSplitSpecLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		words  []string
+		prefix string
+	}{
+		{name: "Empty line", line: ""},
+		{name: "Partial word", line: "git comm", words: []string{"git"}, prefix: "comm"},
+		{name: "Complete words", line: "git commit ", words: []string{"git", "commit"}},
+		{name: "Unterminated quote", line: `git commit -m "fix th`, words: []string{"git", "commit", "-m"}, prefix: "fix th"},
+		{name: "Quoted word with blank", line: `git commit -m "fix th" `, words: []string{"git", "commit", "-m", "fix th"}},
+		{name: "Escaped blank", line: `ls my\ `, words: []string{"ls"}, prefix: "my "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, prefix := splitSpecLine(test.line)
+
+			if !slices.Equal(words, test.words) || prefix != test.prefix {
+				t.Errorf("splitSpecLine() = %q, %q, want %q, %q", words, prefix, test.words, test.prefix)
+			}
+		})
+	}
+}
+
+func TestCommandSpec_walk(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   []string
+		cmd     string
+		args    []string
+		pending string
+		noFlags bool
+	}{
+		{name: "Root", cmd: ""},
+		{name: "Subcommands", words: []string{"git", "commit"}, cmd: "commit"},
+		{name: "Alias", words: []string{"g", "push"}, cmd: "push"},
+		{name: "Global flag", words: []string{"-v", "git"}, cmd: "git"},
+		{name: "Pending long flag", words: []string{"git", "commit", "--message"}, cmd: "commit", pending: "message"},
+		{name: "Inline flag value", words: []string{"git", "commit", "--message=fix"}, cmd: "commit"},
+		{name: "Flag value consumed", words: []string{"git", "commit", "-m", "fix", "file"}, cmd: "commit", args: []string{"file"}},
+		{name: "Bool flag", words: []string{"git", "commit", "--all"}, cmd: "commit"},
+		{name: "Stacked shorts with value last", words: []string{"git", "commit", "-am"}, cmd: "commit", pending: "message"},
+		{name: "Stacked shorts with inline value", words: []string{"git", "commit", "-mfix"}, cmd: "commit"},
+		{name: "Parent command flag", words: []string{"git", "-C"}, cmd: "git", pending: "dir"},
+		{name: "Arguments", words: []string{"git", "push", "origin", "main"}, cmd: "push", args: []string{"origin", "main"}},
+		{name: "No subcommand after arguments", words: []string{"git", "push", "origin", "push"}, cmd: "push", args: []string{"origin", "push"}},
+		{
+			name: "Double dash", words: []string{"git", "commit", "--", "-m"}, cmd: "commit",
+			args: []string{"-m"}, noFlags: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := testSpec().walk(test.words)
+
+			if state.cmd.Name != test.cmd {
+				t.Errorf("walk() command = %q, want %q", state.cmd.Name, test.cmd)
+			}
+
+			if !slices.Equal(state.args, test.args) {
+				t.Errorf("walk() args = %q, want %q", state.args, test.args)
+			}
+
+			var pending string
+			if state.pending != nil {
+				pending = state.pending.Name
+			}
+
+			if pending != test.pending {
+				t.Errorf("walk() pending flag = %q, want %q", pending, test.pending)
+			}
+
+			if state.noFlags != test.noFlags {
+				t.Errorf("walk() noFlags = %v, want %v", state.noFlags, test.noFlags)
+			}
+		})
+	}
+}
+
+func TestSpecState_arg(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		want  string
+	}{
+		{name: "No arguments", words: []string{"git", "commit"}},
+		{name: "First argument", words: []string{"git", "push"}, want: "remote"},
+		{name: "Variadic argument", words: []string{"git", "push", "origin"}, want: "refs"},
+		{name: "Variadic argument repeated", words: []string{"git", "push", "origin", "main", "dev"}, want: "refs"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			if arg := testSpec().walk(test.words).arg(); arg != nil {
+				got = arg.Name
+			}
+
+			if got != test.want {
+				t.Errorf("specState.arg() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCommandSpec_Completer(t *testing.T) {
+	callbacks := map[string]SpecCallback{
+		"refs": func(args []string, _ string) Completions {
+			return CompleteValues("main", "dev").Usage("refs after %v", args)
+		},
+	}
+
+	tests := []struct {
+		name   string
+		line   string
+		values []string
+		usage  string
+	}{
+		{name: "Commands", line: "", values: []string{"g", "git"}},
+		{name: "Subcommands", line: "git ", values: []string{"commit", "push"}, usage: "git <command> [flags] -- version control"},
+		{name: "Flags", line: "git commit -", values: []string{"--all", "--cleanup", "--message", "--verbose", "-a", "-m", "-v"}},
+		{name: "Flag value", line: "git commit --cleanup ", values: []string{"strip", "verbatim"}, usage: "--cleanup <enum>"},
+		{name: "Described flag value", line: "git commit -m ", usage: "-m, --message <string>: commit message"},
+		{
+			name: "Inline flag value", line: "git commit --cleanup=s",
+			values: []string{"--cleanup=strip", "--cleanup=verbatim"}, usage: "--cleanup <enum>",
+		},
+		{name: "Unknown inline flag", line: "git commit --unknown=", usage: ""},
+		{name: "Argument", line: "git push ", values: []string{"origin", "upstream"}, usage: "remote: remote name"},
+		{name: "Callback", line: "git push origin ", values: []string{"dev", "main"}, usage: "refs after [origin]"},
+		{name: "Variadic callback", line: "git push origin main ", values: []string{"dev", "main"}, usage: "refs after [origin main]"},
+		{name: "No flags after double dash", line: "git push -- -", values: []string{"origin", "upstream"}, usage: "remote: remote name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := []rune(test.line)
+			comps := testSpec().Completer(callbacks)(line, len(line))
+
+			var values []string
+			for _, val := range comps.values {
+				values = append(values, val.Value)
+			}
+
+			slices.Sort(values)
+			slices.Sort(test.values)
+
+			if !slices.Equal(values, test.values) {
+				t.Errorf("Completer() values = %q, want %q", values, test.values)
+			}
+
+			if test.usage != "" && comps.usage != test.usage {
+				t.Errorf("Completer() usage = %q, want %q", comps.usage, test.usage)
+			}
+		})
+	}
+}
+
+func TestLoadCommandSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "JSON",
+			data: `{"commands": [{"name": "git", "flags": [{"name": "dir", "short": "C", "type": "dir"}]}]}`,
+		},
+		{
+			name: "YAML",
+			data: "commands:\n  - name: git\n    flags:\n      - name: dir\n        short: C\n        type: dir\n",
+		},
+		{name: "Invalid JSON", data: `{"commands": [`, wantErr: true},
+		{name: "Invalid YAML", data: "commands: [", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec, err := LoadCommandSpec([]byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("LoadCommandSpec() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if len(spec.Commands) != 1 || len(spec.Commands[0].Flags) != 1 {
+				t.Fatalf("LoadCommandSpec() = %+v, want one command with one flag", spec)
+			}
+
+			if flag := spec.Commands[0].Flags[0]; flag.Name != "dir" || flag.Short != "C" || flag.Type != "dir" {
+				t.Errorf("LoadCommandSpec() flag = %+v, want dir, C, dir", flag)
+			}
+		})
+	}
+}
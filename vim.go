@@ -1,9 +1,13 @@
 package readline
 
 import (
+	"regexp"
+	"strings"
 	"unicode"
 
 	"github.com/reeflective/readline/inputrc"
+	"github.com/reeflective/readline/internal/color"
+	"github.com/reeflective/readline/internal/core"
 	"github.com/reeflective/readline/internal/keymap"
 	"github.com/reeflective/readline/internal/strutil"
 )
@@ -33,7 +37,8 @@ func (rl *Shell) viCommands() commands {
 		"vi-visual-mode":    rl.viVisualMode,
 		"vi-editing-mode":   rl.viInsertMode,
 
-		"vi-visual-line-mode": rl.viVisualLineMode,
+		"vi-visual-line-mode":  rl.viVisualLineMode,
+		"vi-visual-block-mode": rl.viVisualBlockMode,
 
 		// Movement
 		"vi-backward-char":    rl.viBackwardChar,
@@ -52,6 +57,11 @@ func (rl *Shell) viCommands() commands {
 		"vi-back-to-indent":   rl.viBackToIndent,
 		"vi-first-print":      rl.viFirstPrint,
 		"vi-goto-mark":        rl.viGotoMark,
+		"vi-goto-mark-line":   rl.viGotoMarkLine,
+		"vi-jump-older":       rl.viJumpOlder,
+		"vi-jump-newer":       rl.viJumpNewer,
+		"vi-change-older":     rl.viChangeOlder,
+		"vi-change-newer":     rl.viChangeNewer,
 
 		"vi-backward-end-word":    rl.viBackwardWordEnd,
 		"vi-backward-end-bigword": rl.viBackwardBlankWordEnd,
@@ -96,6 +106,12 @@ func (rl *Shell) viCommands() commands {
 		"select-in-blank-word": rl.viSelectInBlankWord,
 		"select-in-shell-word": rl.viSelectInShellWord,
 		"select-in-word":       rl.viSelectInWord,
+		"select-an-argument":   rl.viSelectAnArgument,
+		"select-in-argument":   rl.viSelectInArgument,
+		"select-an-indent":     rl.viSelectAnIndent,
+		"select-in-indent":     rl.viSelectInIndent,
+		"select-a-buffer":      rl.viSelectABuffer,
+		"select-in-buffer":     rl.viSelectInBuffer,
 		"vi-select-inside":     rl.viSelectInside,
 		"vi-select-surround":   rl.viSelectSurround,
 
@@ -119,6 +135,9 @@ func (rl *Shell) viCommands() commands {
 		"vi-search-backward":       rl.viSearchBackward,
 		"vi-search-again-forward":  rl.viSearchAgainForward,
 		"vi-search-again-backward": rl.viSearchAgainBackward,
+		"vi-search-word-forward":   rl.viSearchWordForward,
+		"vi-search-word-backward":  rl.viSearchWordBackward,
+		"vi-ex-command":            rl.viExCommand,
 	}
 }
 
@@ -134,6 +153,7 @@ func (rl *Shell) viInsertMode() {
 	rl.selection.Reset()
 	rl.Iterations.Reset()
 	rl.Buffers.Reset()
+	rl.block = nil
 
 	// Change the keymap and mark the insertion point.
 	rl.Keymap.SetLocal("")
@@ -143,8 +163,9 @@ func (rl *Shell) viInsertMode() {
 
 // Enter Vim command mode.
 func (rl *Shell) viCommandMode() {
-	// Reset any visual selection and iterations.
+	// Reset any visual selection, cursors and iterations.
 	rl.selection.Reset()
+	rl.selection.ResetCursors()
 	rl.Iterations.Reset()
 	rl.Buffers.Reset()
 
@@ -155,11 +176,19 @@ func (rl *Shell) viCommandMode() {
 	rl.completer.Reset()
 	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
 
-	// Only go back if not in insert mode
-	if rl.Keymap.Main() == keymap.ViInsert && !rl.cursor.AtBeginningOfLine() {
+	// Only go back if not in insert mode. Text inserted in
+	// a visual block is copied on all of its lines, and the
+	// cursor goes back to the beginning of the insertion.
+	switch {
+	case rl.block != nil && rl.Keymap.Main() == keymap.ViInsert:
+		rl.block.Apply()
+		rl.cursor.Set(rl.block.Pos())
+	case rl.Keymap.Main() == keymap.ViInsert && !rl.cursor.AtBeginningOfLine():
 		rl.cursor.Dec()
 	}
 
+	rl.block = nil
+
 	// Update the cursor position, keymap and insertion point.
 	rl.cursor.CheckCommand()
 	rl.Keymap.SetLocal("")
@@ -201,9 +230,37 @@ func (rl *Shell) viVisualLineMode() {
 	rl.Keymap.PrintCursor(keymap.Visual)
 }
 
+// Enter Vim visual block mode: the selection is the rectangle of text between
+// the lines and columns of the cursor and of its position when entering the mode.
+// If already in visual mode, the selection is kept and becomes a block.
+func (rl *Shell) viVisualBlockMode() {
+	rl.History.SkipSave()
+	rl.Iterations.Reset()
+	rl.Buffers.Reset()
+
+	rl.Hint.Reset()
+	rl.completer.Reset()
+
+	if rl.Keymap.Local() != keymap.Visual || !rl.selection.Active() {
+		rl.selection.Mark(rl.cursor.Pos())
+	}
+
+	rl.selection.VisualBlock()
+	rl.Keymap.SetLocal(keymap.Visual)
+
+	rl.Keymap.PrintCursor(keymap.Visual)
+}
+
 // Go to the beginning of the current line, and enter Vim insert mode.
+// In visual block mode, insert before the block on each of its lines.
 func (rl *Shell) viInsertBol() {
 	rl.Iterations.Reset()
+
+	if rl.selection.IsVisualBlock() {
+		rl.viInsertBlock(false)
+		return
+	}
+
 	rl.beginningOfLine()
 	rl.viInsertMode()
 }
@@ -218,9 +275,15 @@ func (rl *Shell) viAddNext() {
 }
 
 // Go to the end of the current line, and enter insert mode.
+// In visual block mode, append after the block on each of its lines.
 func (rl *Shell) viAddEol() {
 	rl.Iterations.Reset()
 
+	if rl.selection.IsVisualBlock() {
+		rl.viInsertBlock(true)
+		return
+	}
+
 	if rl.Keymap.Local() == keymap.Visual {
 		rl.cursor.Inc()
 		rl.viInsertMode()
@@ -396,6 +459,7 @@ func (rl *Shell) viForwardBlankWordEnd() {
 // going past the end of the line to find one, and then go to the matching bracket.
 func (rl *Shell) viMatchBracket() {
 	rl.History.SkipSave()
+	defer rl.jumpFrom(rl.cursor.Pos())
 
 	nextPos := rl.cursor.Pos()
 	found := false
@@ -463,11 +527,13 @@ func (rl *Shell) viGotoColumn() {
 }
 
 // Move to the end of the line, vi-style.
+// In visual block mode, the block is extended to the end of all its lines.
 func (rl *Shell) viEndOfLine() {
 	rl.History.SkipSave()
 	// We use append so that any y$ / d$
 	// will include the last character.
 	rl.cursor.EndOfLineAppend()
+	rl.selection.ExtendBlockToEnd()
 }
 
 // Move to the first non-blank character after cursor.
@@ -482,24 +548,87 @@ func (rl *Shell) viBackToIndent() {
 	rl.cursor.ToFirstNonSpace(true)
 }
 
-// Move to the specified mark.
+// Read a mark name from the keyboard, and move to the position of this mark: either a mark
+// set with vi-set-mark (a-z), the position before the last jump (` or '), the position of
+// the last change (.), or the position where insert mode was last entered (^).
 func (rl *Shell) viGotoMark() {
-	switch {
-	case rl.selection.Active():
-		// We either an active selection, in which case
-		// we go to the position (begin or end) that is
-		// set and not equal to the cursor.
-		bpos, epos := rl.selection.Pos()
-		if bpos != rl.cursor.Pos() {
-			rl.cursor.Set(bpos)
-		} else {
-			rl.cursor.Set(epos)
+	rl.History.SkipSave()
+
+	pos, found := rl.viReadMark()
+	if !found {
+		return
+	}
+
+	rl.marks.Jump(rl.cursor.Pos())
+	rl.cursor.Set(pos)
+}
+
+// Read a mark name from the keyboard, and move to the first non-blank
+// character of the line of this mark (see vi-goto-mark for mark names).
+func (rl *Shell) viGotoMarkLine() {
+	rl.History.SkipSave()
+
+	pos, found := rl.viReadMark()
+	if !found {
+		return
+	}
+
+	rl.marks.Jump(rl.cursor.Pos())
+	rl.cursor.Set(pos)
+	rl.cursor.BeginningOfLine()
+	rl.cursor.ToFirstNonSpace(true)
+}
+
+// Go to the previous position in the jumplist, which records the
+// positions from which the cursor jumped (with gg, %, marks, etc).
+func (rl *Shell) viJumpOlder() {
+	rl.History.SkipSave()
+
+	vii := rl.Iterations.Get()
+
+	for i := 1; i <= vii; i++ {
+		if pos, found := rl.marks.JumpOlder(rl.cursor.Pos()); found {
+			rl.cursor.Set(pos)
+		}
+	}
+}
+
+// Go to the next position in the jumplist.
+func (rl *Shell) viJumpNewer() {
+	rl.History.SkipSave()
+
+	vii := rl.Iterations.Get()
+
+	for i := 1; i <= vii; i++ {
+		if pos, found := rl.marks.JumpNewer(); found {
+			rl.cursor.Set(pos)
 		}
+	}
+}
+
+// Go to the position of the previous change in the change list.
+func (rl *Shell) viChangeOlder() {
+	rl.History.SkipSave()
 
-	case rl.cursor.Mark() != -1:
-		// Or we go to the cursor mark, which was set when
-		// entering insert mode. This might have no effect.
-		rl.cursor.Set(rl.cursor.Mark())
+	vii := rl.Iterations.Get()
+
+	for i := 1; i <= vii; i++ {
+		if pos, found := rl.marks.ChangeOlder(); found {
+			rl.cursor.Set(pos)
+		}
+	}
+}
+
+// Go to the position of the next change in the change list.
+func (rl *Shell) viChangeNewer() {
+	rl.History.SkipSave()
+
+	vii := rl.Iterations.Get()
+
+	for i := 1; i <= vii; i++ {
+		if pos, found := rl.marks.ChangeNewer(); found {
+			rl.cursor.Set(pos)
+		}
 	}
 }
 
@@ -550,6 +679,22 @@ func (rl *Shell) viChangeTo() {
 		(*rl.line)[bpos] = bchar
 		(*rl.line)[epos] = echar
 
+	case rl.selection.IsVisualBlock():
+		// In visual block mode, delete the block and insert on each of its lines.
+		rl.History.Save()
+
+		cut, block := rl.selection.ChangeBlock()
+		if block == nil {
+			rl.viCommandMode()
+			return
+		}
+
+		rl.Buffers.Write([]rune(cut)...)
+
+		rl.viInsertMode()
+		rl.cursor.Set(block.Pos())
+		rl.block = block
+
 	case rl.selection.Active():
 		// In visual mode, we have just have a selection to delete.
 		rl.History.Save()
@@ -1193,6 +1338,40 @@ func (rl *Shell) viSelectInWord() {
 	rl.selection.Mark(bpos)
 }
 
+// Select a function or command argument including the separator and blanks after it (or
+// before it if it is the last one). Inside brackets, arguments are separated by commas
+// (or by blanks if there are none), and otherwise by blanks, as in shell commands.
+func (rl *Shell) viSelectAnArgument() {
+	rl.viSelectObject(core.ArgumentObject, true)
+}
+
+// Select a function or command argument. Inside brackets, arguments are separated
+// by commas (or by blanks if there are none), and otherwise by blanks.
+func (rl *Shell) viSelectInArgument() {
+	rl.viSelectObject(core.ArgumentObject, false)
+}
+
+// Select the block of lines having the same or a deeper indentation
+// than the current one, with the line above it (usually its header).
+func (rl *Shell) viSelectAnIndent() {
+	rl.viSelectObject(core.IndentObject, true)
+}
+
+// Select the block of lines having the same or a deeper indentation than the current one.
+func (rl *Shell) viSelectInIndent() {
+	rl.viSelectObject(core.IndentObject, false)
+}
+
+// Select the entire buffer.
+func (rl *Shell) viSelectABuffer() {
+	rl.viSelectObject(core.EntireObject, true)
+}
+
+// Select the entire buffer, without leading and trailing blanks.
+func (rl *Shell) viSelectInBuffer() {
+	rl.viSelectObject(core.EntireObject, false)
+}
+
 // Read a key from the keyboard, and attempt to select a region surrounded by those keys.
 // If the key triggering this command is 'i', the selection excludes the surrounding chars.
 func (rl *Shell) viSelectInside() {
@@ -1209,12 +1388,18 @@ func (rl *Shell) viSelectInside() {
 		inside = true
 	}
 
-	// Then use the next key as the surrounding character.
+	// Then use the next key as the surrounding character,
+	// unless it is the key of a user-defined text object.
 	char, empty := rl.Keys.Pop()
 	if empty {
 		return
 	}
 
+	if object, found := rl.TextObjects[rune(char)]; found {
+		rl.viSelectObject(object, !inside)
+		return
+	}
+
 	bpos, epos, _, _ := rl.line.FindSurround(rune(char), rl.cursor.Pos())
 	if bpos == -1 && epos == -1 {
 		return
@@ -1231,6 +1416,21 @@ func (rl *Shell) viSelectInside() {
 	rl.cursor.Set(epos)
 }
 
+// viSelectObject selects a text object (inner or outer) at the cursor, if any.
+func (rl *Shell) viSelectObject(object TextObject, around bool) {
+	rl.History.SkipSave()
+
+	bpos, epos := object(*rl.line, rl.cursor.Pos(), around)
+	if bpos == -1 || epos == -1 {
+		return
+	}
+
+	// Select the range and return: the caller will decide what
+	// to do with the cursor position and the selection itself.
+	rl.selection.Mark(bpos)
+	rl.cursor.Set(epos)
+}
+
 // Read a key from the keyboard, and attempt to create a selection
 // consisting of a pair of this character, if any such pair can be found.
 func (rl *Shell) viSelectSurround() {
@@ -1286,9 +1486,9 @@ func (rl *Shell) viSearch() {
 	rl.completer.NonIsearchStart(rl.History.Name()+" "+string(keys[0]), false, forward, true)
 }
 
-// Search again, through the history for the string of characters
-// between the start of the current line and the point, using the
-// same search string used by the previous search.
+// Search again for the string used by the previous search: the cursor moves to its next
+// match in the line if any, otherwise to the next history line matching it, otherwise
+// the search wraps around the line. Matches of the search string are highlighted.
 // This is a non-incremental search.
 func (rl *Shell) viSearchAgain() {
 	var forward bool
@@ -1305,12 +1505,7 @@ func (rl *Shell) viSearchAgain() {
 		hint = " ?"
 	}
 
-	rl.completer.NonIsearchStart(rl.History.Name()+hint, true, forward, true)
-
-	line, cursor, _ := rl.completer.GetBuffer()
-
-	rl.History.InsertMatch(line, cursor, true, forward, true)
-	rl.completer.NonIsearchStop()
+	rl.viSearchNext(rl.History.Name()+hint, forward)
 }
 
 // Start a new numeric argument, or add to the current one.
@@ -1355,10 +1550,20 @@ func (rl *Shell) viCharSearch() {
 	}
 }
 
-// Set the specified mark at the cursor position.
+// Read a mark name from the keyboard (a-z), and set this mark at the cursor position.
+// Using ` or ' sets the position to which the next vi-goto-mark ` will jump.
 func (rl *Shell) viSetMark() {
 	rl.History.SkipSave()
-	rl.selection.Mark(rl.cursor.Pos())
+
+	done := rl.Keymap.PendingCursor()
+	defer done()
+
+	key, isAbort := rl.Keys.ReadKey()
+	if isAbort || !(key >= 'a' && key <= 'z' || key == '`' || key == '\'') {
+		return
+	}
+
+	rl.marks.Set(key, rl.cursor.Pos())
 }
 
 // Invoke an editor on the current command line, and execute the result as shell commands.
@@ -1474,24 +1679,28 @@ func (rl *Shell) viSearchBackward() {
 	rl.completer.NonIsearchStart(rl.History.Name()+" ?", false, false, true)
 }
 
-// Reuses the last vi-search buffer and finds the previous search match occurrence in the history.
+// Reuses the last vi-search buffer and finds the next search match occurrence,
+// in the line or in the history (see vi-search-again).
 func (rl *Shell) viSearchAgainForward() {
-	rl.completer.NonIsearchStart(rl.History.Name()+" /", true, true, true)
-
-	line, cursor, _ := rl.completer.GetBuffer()
-
-	rl.History.InsertMatch(line, cursor, true, true, true)
-	rl.completer.NonIsearchStop()
+	rl.viSearchNext(rl.History.Name()+" /", true)
 }
 
-// Reuses the last vi-search buffer and finds the next search match occurrence in the history.
+// Reuses the last vi-search buffer and finds the previous search match occurrence,
+// in the line or in the history (see vi-search-again).
 func (rl *Shell) viSearchAgainBackward() {
-	rl.completer.NonIsearchStart(rl.History.Name()+" ?", true, false, true)
+	rl.viSearchNext(rl.History.Name()+" ?", false)
+}
 
-	line, cursor, _ := rl.completer.GetBuffer()
+// Search forward for the next occurrence of the word under the cursor in the line,
+// wrapping around the line. The word becomes the string searched by vi-search-again.
+func (rl *Shell) viSearchWordForward() {
+	rl.viSearchWord(true)
+}
 
-	rl.History.InsertMatch(line, cursor, true, false, true)
-	rl.completer.NonIsearchStop()
+// Search backward for the previous occurrence of the word under the cursor in the line,
+// wrapping around the line. The word becomes the string searched by vi-search-again.
+func (rl *Shell) viSearchWordBackward() {
+	rl.viSearchWord(false)
 }
 
 //
@@ -1528,3 +1737,157 @@ func (rl *Shell) adjustSelectionPending() {
 		rl.selection.Visual(false)
 	}
 }
+
+// viInsertBlock enters insert mode before or after a visual block,
+// so that text inserted on its first line is copied on all others.
+func (rl *Shell) viInsertBlock(after bool) {
+	rl.History.Save()
+
+	block := rl.selection.InsertBlock(after)
+	if block == nil {
+		rl.viCommandMode()
+		return
+	}
+
+	rl.viInsertMode()
+	rl.cursor.Set(block.Pos())
+	rl.block = block
+}
+
+// viReadMark reads a mark name from the keyboard, and returns its position if set.
+func (rl *Shell) viReadMark() (pos int, found bool) {
+	done := rl.Keymap.PendingCursor()
+	defer done()
+
+	key, isAbort := rl.Keys.ReadKey()
+	if isAbort {
+		return 0, false
+	}
+
+	if key == '^' {
+		return rl.cursor.Mark(), rl.cursor.Mark() != -1
+	}
+
+	return rl.marks.Get(key)
+}
+
+// jumpFrom records a jump from pos in the jumplist, if the cursor has moved.
+func (rl *Shell) jumpFrom(pos int) {
+	if rl.cursor.Pos() != pos {
+		rl.marks.Jump(pos)
+	}
+}
+
+// viHighlightSearch highlights all matches of a search string in the line,
+// and moves the cursor to the first (or the last if not forward) of them.
+// If word is true, only whole words matching the search are highlighted.
+func (rl *Shell) viHighlightSearch(search string, forward, word bool) {
+	if search == "" {
+		rl.selection.SetSearch(nil)
+		return
+	}
+
+	rl.viSetSearch(search, word)
+
+	from := -1
+	if !forward {
+		from = rl.line.Len() + 1
+	}
+
+	if pos, _, found := rl.selection.NextMatch(from, forward); found {
+		rl.cursor.Set(pos)
+	}
+}
+
+// viSetSearch sets the search string highlighted and navigated in the line.
+func (rl *Shell) viSetSearch(search string, word bool) {
+	if word {
+		rl.selection.SetSearchWord(search)
+	} else {
+		rl.selection.SetSearch(regexp.MustCompile(regexp.QuoteMeta(search)))
+	}
+}
+
+// viSearchNext moves the cursor to the next match of the last search in the line,
+// wrapping around the line. If the line has no match, the next history line matching
+// the search string is used instead.
+func (rl *Shell) viSearchNext(name string, forward bool) {
+	rl.History.SkipSave()
+
+	rl.completer.NonIsearchStart(name, true, forward, true)
+	line, cursor, _ := rl.completer.GetBuffer()
+	search := string(*line)
+	word := rl.selection.SearchWord()
+
+	// The search is highlighted again if it was cleared.
+	if rl.selection.Search() == nil && search != "" {
+		rl.viSetSearch(search, word)
+	}
+
+	// Matches in the line first, wrapping around it.
+	if pos, wrapped, found := rl.selection.NextMatch(rl.cursor.Pos(), forward); found {
+		rl.completer.NonIsearchStop()
+
+		switch {
+		case wrapped && forward:
+			rl.Hint.SetTemporary(color.FgRed + "search hit BOTTOM, continuing at TOP" + color.Reset)
+		case wrapped:
+			rl.Hint.SetTemporary(color.FgRed + "search hit TOP, continuing at BOTTOM" + color.Reset)
+		}
+
+		rl.marks.Jump(rl.cursor.Pos())
+		rl.cursor.Set(pos)
+
+		return
+	}
+
+	// Then matching lines in history.
+	current := string(*rl.line)
+
+	rl.History.InsertMatch(line, cursor, true, forward, true)
+	rl.completer.NonIsearchStop()
+
+	if string(*rl.line) != current {
+		rl.History.Save()
+		rl.viHighlightSearch(search, forward, word)
+
+		return
+	}
+
+	if search != "" {
+		rl.Hint.SetTemporary(color.FgRed + "Pattern not found: " + search + color.Reset)
+	}
+}
+
+// viSearchWord searches the word under the cursor in the line.
+func (rl *Shell) viSearchWord(forward bool) {
+	rl.History.SkipSave()
+
+	bpos, epos := rl.line.SelectWord(rl.cursor.Pos())
+	if rl.line.Len() == 0 || epos < bpos {
+		return
+	}
+
+	word := string((*rl.line)[bpos : epos+1])
+	if strings.TrimSpace(word) == "" {
+		return
+	}
+
+	rl.completer.NonIsearchSetLast(word)
+	rl.selection.SetSearchWord(word)
+
+	pos, wrapped, found := rl.selection.NextMatch(rl.cursor.Pos(), forward)
+
+	switch {
+	case !found:
+		rl.Hint.SetTemporary(color.FgRed + "Pattern not found: " + word + color.Reset)
+		return
+	case wrapped && forward:
+		rl.Hint.SetTemporary(color.FgRed + "search hit BOTTOM, continuing at TOP" + color.Reset)
+	case wrapped:
+		rl.Hint.SetTemporary(color.FgRed + "search hit TOP, continuing at BOTTOM" + color.Reset)
+	}
+
+	rl.marks.Jump(rl.cursor.Pos())
+	rl.cursor.Set(pos)
+}
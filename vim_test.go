@@ -0,0 +1,55 @@
+package readline
+
+import (
+	"testing"
+
+	"github.com/reeflective/readline/internal/keymap"
+)
+
+func TestShell_viChangeTo_emptyBlock(t *testing.T) {
+	rl := NewShell()
+
+	// Changing a visual block on an empty line does nothing.
+	rl.viVisualBlockMode()
+	rl.viChangeTo()
+
+	if rl.block != nil {
+		t.Errorf("viChangeTo() started a block insertion on an empty line")
+	}
+
+	if rl.selection.Active() || rl.Keymap.Local() != "" || rl.Keymap.Main() != keymap.ViCommand {
+		t.Errorf("viChangeTo() did not leave visual block mode on an empty line")
+	}
+}
+
+func TestShell_viSearchAgain(t *testing.T) {
+	rl := NewShell()
+	rl.History.Current().Write("foo baz")
+
+	// Matches in the line wrap around it, before searching history.
+	rl.line.Set([]rune("foo bar foo")...)
+	rl.viSearchWordForward()
+	rl.viSearchAgainBackward()
+	rl.viSearchAgainBackward()
+
+	if line := string(*rl.line); line != "foo bar foo" {
+		t.Errorf("line = %q, want %q", line, "foo bar foo")
+	}
+
+	if rl.cursor.Pos() != 8 {
+		t.Errorf("Cursor.Pos() = %d, want 8", rl.cursor.Pos())
+	}
+
+	// Lines without matches use history, and whole-word searches remain.
+	rl.line.Set([]rune("bar")...)
+	rl.cursor.Set(0)
+	rl.viSearchAgainBackward()
+
+	if line := string(*rl.line); line != "foo baz" {
+		t.Errorf("line = %q, want %q", line, "foo baz")
+	}
+
+	if !rl.selection.SearchWord() {
+		t.Errorf("Selection.SearchWord() = false after moving to a history line")
+	}
+}